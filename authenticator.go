@@ -0,0 +1,49 @@
+package otp
+
+import "github.com/sshilin/otp/qr"
+
+// Authenticator covers the lifecycle of a TOTP secret: enrolling it into an
+// authenticator app, generating the current code and verifying codes entered
+// by the user. Use the TOTP instance directly for anything more advanced.
+type Authenticator struct {
+	totp   *totp
+	secret Secret
+}
+
+// NewAuthenticator creates a new Authenticator for the secret. The options are
+// applied on top of the TOTP defaults, whose skew of 1 time step tolerates the
+// usual clock drifts of phones.
+func NewAuthenticator(secret Secret, opts ...TotpOption) *Authenticator {
+	return &Authenticator{
+		totp:   NewTotp(opts...),
+		secret: secret,
+	}
+}
+
+// Secret returns the secret key of the Authenticator for storage.
+func (a *Authenticator) Secret() Secret {
+	return a.secret
+}
+
+// URI returns the otpauth:// provisioning URI for enrolling the secret into an
+// authenticator app.
+func (a *Authenticator) URI(issuer, account string) string {
+	return a.totp.URI(issuer, account, a.secret)
+}
+
+// QR renders the provisioning URI as a PNG image of a QR code for scanning
+// with an authenticator app.
+func (a *Authenticator) QR(issuer, account string) ([]byte, error) {
+	return qr.PNG(a.URI(issuer, account))
+}
+
+// Code generates the code for the current time of the configured clock.
+func (a *Authenticator) Code() string {
+	return a.totp.Current(a.secret)
+}
+
+// Verify validates the code at the current time of the configured clock,
+// accepting the time steps within the skew.
+func (a *Authenticator) Verify(code string) bool {
+	return a.totp.Validate(a.secret, code)
+}
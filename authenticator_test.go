@@ -0,0 +1,43 @@
+package otp
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestAuthenticator(t *testing.T) {
+	secret, _ := ParseBase32("GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ")
+	a := NewAuthenticator(secret, WithClock(fixedClock(time.Unix(89, 0))))
+	if !bytes.Equal(a.Secret(), secret) {
+		t.Logf("Expected secret %x, but was %x", secret, a.Secret())
+		t.Fail()
+	}
+	expected := "otpauth://totp/Example:alice?secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ&issuer=Example&algorithm=SHA1&digits=6&period=30"
+	if uri := a.URI("Example", "alice"); uri != expected {
+		t.Logf("Expected %s, but was %s", expected, uri)
+		t.Fail()
+	}
+	if png, err := a.QR("Example", "alice"); err != nil || !bytes.HasPrefix(png, []byte("\x89PNG")) {
+		t.Logf("Expected a PNG image, but was %v", err)
+		t.Fail()
+	}
+	if code := a.Code(); code != "359152" {
+		t.Logf("Expected code %s, but was %s", "359152", code)
+		t.Fail()
+	}
+	for _, code := range []string{"287082", "359152", "969429"} {
+		if !a.Verify(code) {
+			t.Logf("Code %s expected to be valid within the skew", code)
+			t.Fail()
+		}
+	}
+	if a.Verify("755224") {
+		t.Logf("Code %s expected to be invalid outside the skew", "755224")
+		t.Fail()
+	}
+	if NewAuthenticator(secret, WithClock(fixedClock(time.Unix(89, 0))), WithSkew(0)).Verify("287082") {
+		t.Logf("Code %s expected to be invalid without skew", "287082")
+		t.Fail()
+	}
+}
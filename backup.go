@@ -0,0 +1,100 @@
+package otp
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	// backupAlphabet holds the symbols of backup codes.
+	backupAlphabet = "0123456789abcdefghijklmnopqrstuvwxyz"
+	// minBackupCodeLength keeps backup codes over 40 bits of entropy.
+	minBackupCodeLength = 8
+)
+
+// ErrBackupCodeLength is returned when generating backup codes shorter than
+// 8 characters.
+var ErrBackupCodeLength = errors.New("otp: backup code must be at least 8 characters")
+
+// ErrBackupCodeCount is returned when generating a negative number of backup
+// codes.
+var ErrBackupCodeCount = errors.New("otp: number of backup codes must not be negative")
+
+// GenerateBackupCodes generates n random one-time backup codes of the given
+// length using crypto/rand. Codes consist of lowercase letters and digits.
+// Store only the hashes of the codes returned by HashBackupCode. Returns
+// ErrBackupCodeCount for a negative n.
+func GenerateBackupCodes(n, length int) ([]string, error) {
+	if n < 0 {
+		return nil, fmt.Errorf("%w, but was %d", ErrBackupCodeCount, n)
+	}
+	if length < minBackupCodeLength {
+		return nil, fmt.Errorf("%w, but was %d", ErrBackupCodeLength, length)
+	}
+	codes := make([]string, n)
+	for i := range codes {
+		code, err := randomCode(length)
+		if err != nil {
+			return nil, err
+		}
+		codes[i] = code
+	}
+
+	return codes, nil
+}
+
+// randomCode draws the symbols of the code uniformly from backupAlphabet by
+// rejecting the random bytes beyond the largest multiple of its length.
+func randomCode(length int) (string, error) {
+	const limit = 256 - 256%len(backupAlphabet)
+	code := make([]byte, 0, length)
+	buf := make([]byte, length)
+	for len(code) < length {
+		if _, err := rand.Read(buf); err != nil {
+			return "", fmt.Errorf("otp: failed to generate backup code: %w", err)
+		}
+		for _, b := range buf {
+			if int(b) < limit && len(code) < length {
+				code = append(code, backupAlphabet[int(b)%len(backupAlphabet)])
+			}
+		}
+	}
+
+	return string(code), nil
+}
+
+// HashBackupCode returns the hex-encoded SHA256 hash of the backup code for
+// storage. The code is matched case-insensitively and spaces or dashes are
+// ignored, so users can enter it grouped.
+func HashBackupCode(code string) string {
+	code = strings.ToLower(strings.NewReplacer(" ", "", "-", "").Replace(code))
+	sum := sha256.Sum256([]byte(code))
+
+	return hex.EncodeToString(sum[:])
+}
+
+// VerifyBackupCode checks the backup code against the stored hashes. On
+// success it returns the hashes without the matched one, consuming the code,
+// and true. Otherwise the hashes are returned unchanged with false. All hashes
+// are compared in constant time, so timing does not reveal which one matched.
+func VerifyBackupCode(code string, hashes []string) ([]string, bool) {
+	hash := HashBackupCode(code)
+	found, matched := 0, 0
+	for i, h := range hashes {
+		eq := subtle.ConstantTimeCompare([]byte(hash), []byte(h))
+		matched = subtle.ConstantTimeSelect(eq&^found, i, matched)
+		found |= eq
+	}
+	if found == 0 {
+		return hashes, false
+	}
+	remaining := make([]string, 0, len(hashes)-1)
+	remaining = append(remaining, hashes[:matched]...)
+
+	return append(remaining, hashes[matched+1:]...), true
+}
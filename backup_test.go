@@ -0,0 +1,60 @@
+package otp
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestGenerateBackupCodes(t *testing.T) {
+	codes, err := GenerateBackupCodes(10, 12)
+	if err != nil || len(codes) != 10 {
+		t.Logf("Expected 10 codes, but was %d (%v)", len(codes), err)
+		t.FailNow()
+	}
+	seen := make(map[string]bool)
+	for _, code := range codes {
+		if len(code) != 12 || strings.Trim(code, backupAlphabet) != "" {
+			t.Logf("Expected 12 alphanumeric characters, but was %q", code)
+			t.Fail()
+		}
+		if seen[code] {
+			t.Logf("Expected unique codes, but %q repeated", code)
+			t.Fail()
+		}
+		seen[code] = true
+	}
+	if _, err := GenerateBackupCodes(10, 7); !errors.Is(err, ErrBackupCodeLength) {
+		t.Logf("Expected %v, but was %v", ErrBackupCodeLength, err)
+		t.Fail()
+	}
+	if _, err := GenerateBackupCodes(-1, 8); !errors.Is(err, ErrBackupCodeCount) {
+		t.Logf("Expected %v, but was %v", ErrBackupCodeCount, err)
+		t.Fail()
+	}
+}
+
+func TestVerifyBackupCode(t *testing.T) {
+	codes := []string{"abcd1234efgh", "ijkl5678mnop", "qrst9012uvwx"}
+	hashes := make([]string, len(codes))
+	for i, code := range codes {
+		hashes[i] = HashBackupCode(code)
+	}
+	remaining, ok := VerifyBackupCode("IJKL-5678-MNOP", hashes)
+	if !ok || len(remaining) != 2 || remaining[0] != hashes[0] || remaining[1] != hashes[2] {
+		t.Logf("Expected the second code consumed, but was %v, %v", ok, remaining)
+		t.FailNow()
+	}
+	if _, ok := VerifyBackupCode("ijkl5678mnop", remaining); ok {
+		t.Logf("Code %s expected to be rejected after use", "ijkl5678mnop")
+		t.Fail()
+	}
+	if unchanged, ok := VerifyBackupCode("zzzzzzzzzzzz", remaining); ok || len(unchanged) != 2 {
+		t.Logf("Expected an unknown code rejected, but was %v, %v", ok, unchanged)
+		t.Fail()
+	}
+	if remaining[0] != hashes[0] || hashes[1] != HashBackupCode(codes[1]) {
+		t.Logf("Expected the stored hashes unchanged")
+		t.Fail()
+	}
+}
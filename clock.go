@@ -0,0 +1,63 @@
+package otp
+
+import "time"
+
+// Clock provides the current time to TOTP instances, so the time source can be
+// replaced in tests.
+type Clock interface {
+	Now() time.Time
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+// WithClock configures the clock to read the current time from. A nil clock
+// restores the default. Default: the system clock.
+func WithClock(c Clock) TotpOption {
+	return totpOption(func(tp *totp) {
+		if c == nil {
+			c = realClock{}
+		}
+		tp.clock = c
+	})
+}
+
+// fixedClock is a clock frozen at a single instant.
+type fixedClock time.Time
+
+func (c fixedClock) Now() time.Time {
+	return time.Time(c)
+}
+
+// WithFixedTime configures a clock frozen at the given time, so Current and
+// Validate always use the same instant, e.g. in examples and golden-file
+// tests. It's a shortcut for WithClock with a clock returning t.
+func WithFixedTime(t time.Time) TotpOption {
+	return WithClock(fixedClock(t))
+}
+
+// Now returns the current time according to the configured clock.
+func (tp *totp) Now() time.Time {
+	return tp.clock.Now()
+}
+
+// CounterNow returns the counter value for the current time of the configured
+// clock, like At(tp.Now()).
+func (tp *totp) CounterNow() Counter {
+	return tp.At(tp.Now())
+}
+
+// Current generates the TOTP code for the current time of the configured
+// clock using the given secret key.
+func (tp *totp) Current(key []byte) string {
+	return tp.Code(key, tp.Now())
+}
+
+// Validate validates a TOTP code at the current time of the configured clock
+// like ValidateAt, accepting the time steps within the configured skew.
+func (tp *totp) Validate(key []byte, code string) bool {
+	return tp.ValidateAt(key, code, tp.Now())
+}
@@ -0,0 +1,122 @@
+package otp
+
+import (
+	"testing"
+	"time"
+)
+
+func TestClock(t *testing.T) {
+	unix := time.Unix(1111111109, 0)
+	totp := NewTotp(WithClock(fixedClock(unix)))
+	if now := totp.Now(); !now.Equal(unix) {
+		t.Logf("Expected %v, but was %v", unix, now)
+		t.Fail()
+	}
+	if c := totp.At(totp.Now()); c != 37037036 {
+		t.Logf("Expected %d, but was %d", 37037036, c)
+		t.Fail()
+	}
+	if c := totp.CounterNow(); c != 37037036 {
+		t.Logf("Expected %d, but was %d", 37037036, c)
+		t.Fail()
+	}
+}
+
+func TestDefaultClock(t *testing.T) {
+	before := time.Now()
+	now := NewTotp().Now()
+	if now.Before(before) || now.After(time.Now()) {
+		t.Logf("Expected the current time, but was %v", now)
+		t.Fail()
+	}
+}
+
+func TestNilClock(t *testing.T) {
+	if now := NewTotp(WithClock(nil)).Now(); now.IsZero() {
+		t.Logf("Expected the current time, but was %v", now)
+		t.Fail()
+	}
+}
+
+func TestCurrent(t *testing.T) {
+	key20 := []byte("12345678901234567890")
+	totp := NewTotp(WithDigits(8), WithClock(fixedClock(time.Unix(1111111109, 0))))
+	if code := totp.Current(key20); code != "07081804" {
+		t.Logf("Expected code %s, but was %s", "07081804", code)
+		t.Fail()
+	}
+}
+
+func TestValidateNow(t *testing.T) {
+	key20 := []byte("12345678901234567890")
+	totp := NewTotp(WithDigits(8), WithSkew(1), WithClock(fixedClock(time.Unix(1111111109, 0))))
+	if !totp.Validate(key20, "07081804") {
+		t.Logf("Code %s expected to be valid", "07081804")
+		t.Fail()
+	}
+	if previous := totp.Code(key20, time.Unix(1111111109-30, 0)); !totp.Validate(key20, previous) {
+		t.Logf("Code %s of the previous step expected to be valid", previous)
+		t.Fail()
+	}
+	if NewTotp(WithDigits(8), WithClock(fixedClock(time.Unix(59, 0)))).Validate(key20, "07081804") {
+		t.Logf("Code %s expected to be invalid at another time", "07081804")
+		t.Fail()
+	}
+}
+
+func TestFixedTime(t *testing.T) {
+	key20 := []byte("12345678901234567890")
+	unix := time.Unix(1111111109, 0)
+	totp := NewTotp(WithDigits(8), WithFixedTime(unix))
+	if now := totp.Now(); !now.Equal(unix) {
+		t.Logf("Expected %v, but was %v", unix, now)
+		t.Fail()
+	}
+	if code := totp.Current(key20); code != "07081804" {
+		t.Logf("Expected code %s, but was %s", "07081804", code)
+		t.Fail()
+	}
+}
+
+func TestRemainingTime(t *testing.T) {
+	testCases := []struct {
+		totp      *totp
+		unix      time.Time
+		remaining time.Duration
+		progress  float64
+	}{
+		{totp: NewTotp(), unix: time.Unix(0, 0), remaining: 30 * time.Second, progress: 0},
+		{totp: NewTotp(), unix: time.Unix(59, 0), remaining: time.Second, progress: 29.0 / 30},
+		{totp: NewTotp(), unix: time.Unix(59, 500000000), remaining: 500 * time.Millisecond, progress: 29.5 / 30},
+		{totp: NewTotp(), unix: time.Unix(60, 0), remaining: 30 * time.Second, progress: 0},
+		{totp: NewTotp(), unix: time.Unix(20000000000, 0), remaining: 10 * time.Second, progress: 20.0 / 30},
+		{totp: NewTotp(WithEpoch(10), WithTimeStep(10*time.Second)), unix: time.Unix(25, 0), remaining: 5 * time.Second, progress: 0.5},
+		{totp: NewTotp(WithEpoch(10), WithTimeStep(10*time.Second)), unix: time.Unix(5, 0), remaining: 15 * time.Second, progress: 0},
+		{totp: NewTotp(WithTimeStep(1500 * time.Millisecond)), unix: time.Unix(2, 0), remaining: time.Second, progress: 1.0 / 3},
+	}
+	for _, tC := range testCases {
+		t.Run("Time remaining in the time step", func(t *testing.T) {
+			if remaining := tC.totp.RemainingTime(tC.unix); remaining != tC.remaining {
+				t.Logf("Expected %v, but was %v", tC.remaining, remaining)
+				t.Fail()
+			}
+			if progress := tC.totp.Progress(tC.unix); progress != tC.progress {
+				t.Logf("Expected %v, but was %v", tC.progress, progress)
+				t.Fail()
+			}
+			expires := tC.totp.ExpiresAt(tC.unix)
+			if !expires.Equal(tC.unix.Add(tC.remaining)) {
+				t.Logf("Expected %v, but was %v", tC.unix.Add(tC.remaining), expires)
+				t.Fail()
+			}
+			if counter := tC.totp.At(expires); counter != tC.totp.At(tC.unix)+1 {
+				t.Logf("Expected counter %d at expiry, but was %d", tC.totp.At(tC.unix)+1, counter)
+				t.Fail()
+			}
+			if tC.totp.NearExpiry(tC.unix, tC.remaining) || !tC.totp.NearExpiry(tC.unix, tC.remaining+1) {
+				t.Logf("Expected near expiry for thresholds above %v only", tC.remaining)
+				t.Fail()
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package otp
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrIncompatible is returned by CheckCompatibility for a configuration that
+// an authenticator app doesn't support.
+var ErrIncompatible = errors.New("otp: incompatible configuration")
+
+// Profile describes the TOTP configurations an authenticator app is known to
+// compute correct codes for. Apps often scan unsupported otpauth URIs without
+// complaints, but silently fall back to their defaults.
+type Profile struct {
+	name   string
+	hashes []string
+	digits []int
+	steps  []time.Duration
+}
+
+var (
+	// GoogleAuthenticator accepts 6 and 8 digit SHA1 codes with a 30 second
+	// time step. Some versions ignore the algorithm and digits parameters.
+	GoogleAuthenticator = Profile{
+		name:   "Google Authenticator",
+		hashes: []string{"SHA1"},
+		digits: []int{6, 8},
+		steps:  []time.Duration{30 * time.Second},
+	}
+	// MicrosoftAuthenticator accepts only the RFC 6238 defaults: 6 digit SHA1
+	// codes with a 30 second time step.
+	MicrosoftAuthenticator = Profile{
+		name:   "Microsoft Authenticator",
+		hashes: []string{"SHA1"},
+		digits: []int{6},
+		steps:  []time.Duration{30 * time.Second},
+	}
+	// Authy accepts 6 to 8 digit SHA1 codes with a 30 second time step, and
+	// its own 10 second time step as configured by NewAuthyTotp.
+	Authy = Profile{
+		name:   "Authy",
+		hashes: []string{"SHA1"},
+		digits: []int{6, 7, 8},
+		steps:  []time.Duration{10 * time.Second, 30 * time.Second},
+	}
+)
+
+// String returns the name of the authenticator app.
+func (p Profile) String() string {
+	return p.name
+}
+
+// CheckCompatibility checks that the configuration falls within the profile
+// of an authenticator app, before handing its URI to users. Returns
+// ErrIncompatible listing every violation, or nil if the app is known to
+// compute the same codes. Custom alphabets, checksum digits, moduli, fixed
+// truncation offsets, extended truncation, little-endian counters, non-zero
+// epochs and rounding other than RoundFloor are supported by none of the apps.
+func (tp *totp) CheckCompatibility(p Profile) error {
+	hp := &tp.hotp
+	var violations []string
+	if name := HashName(hp.hashFunc); !contains(p.hashes, name) {
+		if name == "" {
+			name = "custom"
+		}
+		violations = append(violations, fmt.Sprintf("algorithm %s, must be %s", name, strings.Join(p.hashes, " or ")))
+	}
+	if !contains(p.digits, hp.digits) {
+		digits := make([]string, len(p.digits))
+		for i, n := range p.digits {
+			digits[i] = strconv.Itoa(n)
+		}
+		violations = append(violations, fmt.Sprintf("%d digits, must be %s", hp.digits, strings.Join(digits, " or ")))
+	}
+	if tp.timeStep%time.Second != 0 {
+		violations = append(violations, fmt.Sprintf("time step %v, must be whole seconds", tp.timeStep))
+	} else if !contains(p.steps, tp.timeStep) {
+		steps := make([]string, len(p.steps))
+		for i, step := range p.steps {
+			steps[i] = step.String()
+		}
+		violations = append(violations, fmt.Sprintf("time step %v, must be %s", tp.timeStep, strings.Join(steps, " or ")))
+	}
+	if hp.alphabet != decimalAlphabet {
+		violations = append(violations, "custom alphabet")
+	}
+	if hp.checksum {
+		violations = append(violations, "checksum digit")
+	}
+	if hp.modulus > 0 {
+		violations = append(violations, "modulus")
+	}
+	if hp.truncationOffset >= 0 {
+		violations = append(violations, "fixed truncation offset")
+	} else if hp.extended && hp.hashFunc().Size() > 20 {
+		violations = append(violations, "extended truncation")
+	}
+	if hp.counterOrder == binary.LittleEndian {
+		violations = append(violations, "little-endian counter")
+	} else if hp.counterOrder != binary.BigEndian {
+		violations = append(violations, "custom counter byte order")
+	}
+	if tp.epoch != 0 {
+		violations = append(violations, "non-zero epoch")
+	}
+	if tp.rounding != RoundFloor {
+		violations = append(violations, fmt.Sprintf("rounding %v, must be %v", tp.rounding, RoundFloor))
+	}
+	if len(violations) > 0 {
+		return fmt.Errorf("%w with %s: %s", ErrIncompatible, p.name, strings.Join(violations, "; "))
+	}
+
+	return nil
+}
+
+func contains[T comparable](values []T, v T) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+
+	return false
+}
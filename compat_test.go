@@ -0,0 +1,66 @@
+package otp
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheckCompatibility(t *testing.T) {
+	testCases := []struct {
+		desc       string
+		totp       *totp
+		profile    Profile
+		violations []string
+	}{
+		{desc: "Google defaults", totp: NewTotp(), profile: GoogleAuthenticator},
+		{desc: "Google 8 digits", totp: NewTotp(WithDigits(8)), profile: GoogleAuthenticator},
+		{desc: "Microsoft defaults", totp: NewTotp(), profile: MicrosoftAuthenticator},
+		{desc: "Authy preset", totp: NewAuthyTotp(), profile: Authy},
+		{desc: "Microsoft 8 digits", totp: NewTotp(WithDigits(8)), profile: MicrosoftAuthenticator, violations: []string{"8 digits"}},
+		{desc: "Microsoft SHA256", totp: NewTotp(WithHash(sha256.New)), profile: MicrosoftAuthenticator, violations: []string{"algorithm SHA256"}},
+		{desc: "Google custom hash", totp: NewTotp(WithHash(md5.New)), profile: GoogleAuthenticator, violations: []string{"algorithm custom"}},
+		{desc: "Google 60s", totp: NewTotp(WithTimeStep(time.Minute)), profile: GoogleAuthenticator, violations: []string{"time step 1m0s"}},
+		{desc: "Google 1.5s", totp: NewTotp(WithTimeStep(1500 * time.Millisecond)), profile: GoogleAuthenticator, violations: []string{"time step 1.5s, must be whole seconds"}},
+		{desc: "Google extended truncation", totp: NewTotp(WithHash(sha256.New), WithExtendedTruncation(true)), profile: GoogleAuthenticator, violations: []string{"extended truncation"}},
+		{desc: "Google little-endian counter", totp: NewTotp(WithCounterEndian(binary.LittleEndian)), profile: GoogleAuthenticator, violations: []string{"little-endian counter"}},
+		{desc: "Google nearest rounding", totp: NewTotp(WithRounding(RoundNearest)), profile: GoogleAuthenticator, violations: []string{"rounding RoundNearest"}},
+		{desc: "Steam", totp: NewSteamTotp(), profile: Authy, violations: []string{"5 digits", "custom alphabet"}},
+		{
+			desc:       "several violations",
+			totp:       NewTotp(WithDigits(7), WithChecksum(true), WithEpoch(100), WithTruncationOffset(0), WithModulus(1000)),
+			profile:    MicrosoftAuthenticator,
+			violations: []string{"7 digits", "checksum digit", "modulus", "fixed truncation offset", "non-zero epoch"},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			err := tC.totp.CheckCompatibility(tC.profile)
+			if len(tC.violations) == 0 {
+				if err != nil {
+					t.Logf("Expected no error, but was %v", err)
+					t.Fail()
+				}
+				return
+			}
+			if !errors.Is(err, ErrIncompatible) {
+				t.Logf("Expected %v, but was %v", ErrIncompatible, err)
+				t.FailNow()
+			}
+			for _, violation := range tC.violations {
+				if !strings.Contains(err.Error(), violation) {
+					t.Logf("Expected %q in %v", violation, err)
+					t.Fail()
+				}
+			}
+		})
+	}
+	if name := MicrosoftAuthenticator.String(); name != "Microsoft Authenticator" {
+		t.Logf("Expected %s, but was %s", "Microsoft Authenticator", name)
+		t.Fail()
+	}
+}
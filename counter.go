@@ -0,0 +1,74 @@
+package otp
+
+import "sync/atomic"
+
+// HotpCounter tracks the counter value of a single HOTP token in memory. It's
+// safe for concurrent use.
+type HotpCounter struct {
+	hotp     *hotp
+	counter  atomic.Uint64
+	failures atomic.Int64
+}
+
+// NewHotpCounter creates a new HotpCounter generating and validating codes
+// with the given HOTP instance, starting at the given counter value.
+func NewHotpCounter(hp *hotp, counter Counter) *HotpCounter {
+	hc := &HotpCounter{hotp: hp}
+	hc.counter.Store(uint64(counter))
+
+	return hc
+}
+
+// Counter returns the counter value of the next code.
+func (hc *HotpCounter) Counter() Counter {
+	return Counter(hc.counter.Load())
+}
+
+// Next generates the code for the current counter value and advances the
+// counter, so every call returns a new code.
+func (hc *HotpCounter) Next(key []byte) string {
+	counter := hc.counter.Add(1) - 1
+
+	return hc.hotp.Generate(key, Counter(counter))
+}
+
+// Verify validates the code against the current counter value and up to
+// window counter values ahead of it. On success the counter advances to one
+// past the matched value, so the same code can't be accepted twice. On failure
+// the counter stays, so wrong codes can't push it out of sync with the token.
+// With WithNoResync configured, the window is ignored. Windows of more than
+// 1000 are clamped like ValidateLookAhead does.
+//
+// With a throttle configured, Verify rejects every code once the number of
+// consecutive failures reaches it, until Unlock. Each failure is a guess
+// against window+1 codes, so an attacker succeeds with the probability of at
+// most throttle*(window+1)/CodeSpace() before the lockout: a wider resync
+// window calls for a lower throttle.
+func (hc *HotpCounter) Verify(key []byte, code string, window int) bool {
+	if hc.Locked() {
+		return false
+	}
+	for {
+		counter := hc.counter.Load()
+		ok, matched := hc.hotp.ValidateLookAhead(key, code, Counter(counter), hc.hotp.resyncWindow(window))
+		if !ok {
+			hc.failures.Add(1)
+			return false
+		}
+		if hc.counter.CompareAndSwap(counter, uint64(matched)+1) {
+			hc.failures.Store(0)
+			return true
+		}
+	}
+}
+
+// Locked reports whether the consecutive failures reached the throttle.
+func (hc *HotpCounter) Locked() bool {
+	return hc.hotp.throttle > 0 && hc.failures.Load() >= int64(hc.hotp.throttle)
+}
+
+// Unlock clears the consecutive failures, e.g. after the user was verified by
+// other means.
+func (hc *HotpCounter) Unlock() {
+	hc.failures.Store(0)
+}
@@ -0,0 +1,126 @@
+package otp
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestHotpCounter(t *testing.T) {
+	key20 := []byte("12345678901234567890")
+	hc := NewHotpCounter(NewHotp(), 0)
+	for _, expected := range []string{"755224", "287082", "359152"} {
+		if code := hc.Next(key20); code != expected {
+			t.Logf("Expected code %s, but was %s", expected, code)
+			t.Fail()
+		}
+	}
+	if c := hc.Counter(); c != 3 {
+		t.Logf("Expected counter %d, but was %d", 3, c)
+		t.Fail()
+	}
+}
+
+func TestHotpCounterVerify(t *testing.T) {
+	key20 := []byte("12345678901234567890")
+	hc := NewHotpCounter(NewHotp(), 0)
+	if !hc.Verify(key20, "755224", 3) || hc.Counter() != 1 {
+		t.Logf("Expected counter %d, but was %d", 1, hc.Counter())
+		t.Fail()
+	}
+	if hc.Verify(key20, "755224", 3) {
+		t.Logf("Code %s expected to be rejected on replay", "755224")
+		t.Fail()
+	}
+	if !hc.Verify(key20, "338314", 3) || hc.Counter() != 5 {
+		t.Logf("Expected counter %d, but was %d", 5, hc.Counter())
+		t.Fail()
+	}
+	if hc.Verify(key20, "162583", 1) || hc.Counter() != 5 {
+		t.Logf("Expected counter %d, but was %d", 5, hc.Counter())
+		t.Fail()
+	}
+}
+
+func TestHotpCounterConcurrentVerify(t *testing.T) {
+	key20 := []byte("12345678901234567890")
+	hc := NewHotpCounter(NewHotp(), 0)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	accepted := 0
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if hc.Verify(key20, "359152", 5) {
+				mu.Lock()
+				accepted++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if accepted != 1 || hc.Counter() != 3 {
+		t.Logf("Expected the code accepted once, but was %d times (counter %d)", accepted, hc.Counter())
+		t.Fail()
+	}
+}
+
+func TestHotpCounterThrottle(t *testing.T) {
+	key20 := []byte("12345678901234567890")
+	hc := NewHotpCounter(NewHotp(WithThrottle(3)), 0)
+	for i := 0; i < 3; i++ {
+		if hc.Locked() || hc.Verify(key20, "000000", 5) {
+			t.Logf("Expected failed attempt %d before the lockout", i+1)
+			t.Fail()
+		}
+	}
+	if !hc.Locked() || hc.Verify(key20, "755224", 5) {
+		t.Logf("Expected a valid code rejected after %d failures", 3)
+		t.Fail()
+	}
+	if hc.Counter() != 0 {
+		t.Logf("Expected counter %d, but was %d", 0, hc.Counter())
+		t.Fail()
+	}
+	hc.Unlock()
+	if hc.Locked() || !hc.Verify(key20, "755224", 5) || hc.Counter() != 1 {
+		t.Logf("Expected a valid code accepted after unlock, but counter was %d", hc.Counter())
+		t.Fail()
+	}
+	hc.Verify(key20, "000000", 5)
+	hc.Verify(key20, "000000", 5)
+	hc.Verify(key20, "287082", 5)
+	hc.Verify(key20, "000000", 5)
+	if hc.Locked() {
+		t.Logf("Expected a success to clear the failures")
+		t.Fail()
+	}
+	unthrottled := NewHotpCounter(NewHotp(), 0)
+	for i := 0; i < 10; i++ {
+		unthrottled.Verify(key20, "000000", 5)
+	}
+	if unthrottled.Locked() || !unthrottled.Verify(key20, "755224", 5) {
+		t.Logf("Expected no lockout without throttle")
+		t.Fail()
+	}
+}
+
+func TestHotpCounterNoResync(t *testing.T) {
+	key20 := []byte("12345678901234567890")
+	hc := NewHotpCounter(NewHotp(WithNoResync()), 0)
+	if hc.Verify(key20, "287082", 5) || hc.Counter() != 0 {
+		t.Logf("Expected the code ahead rejected, but counter was %d", hc.Counter())
+		t.Fail()
+	}
+	if !hc.Verify(key20, "755224", 5) || hc.Counter() != 1 {
+		t.Logf("Expected the expected code accepted, but counter was %d", hc.Counter())
+		t.Fail()
+	}
+	store := NewMemoryCounterStore()
+	store.Set("alice", 0)
+	sh := NewStoredHotp(NewHotp(WithNoResync()), store)
+	if ok, err := sh.Verify("alice", key20, "287082", 5); ok || err != nil {
+		t.Logf("Expected the code ahead rejected, but was (%t, %v)", ok, err)
+		t.Fail()
+	}
+}
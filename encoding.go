@@ -0,0 +1,117 @@
+package otp
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrSecretEncoding is returned for an unknown secret encoding.
+var ErrSecretEncoding = errors.New("otp: unknown secret encoding")
+
+// SecretEncoding is the encoding of the string secrets taken by GenerateString
+// and ValidateString of HOTP, and CodeString and ValidateAtString of TOTP.
+type SecretEncoding int
+
+const (
+	// EncodingBase32 decodes secrets like ParseBase32, as shown by
+	// authenticator apps and carried by provisioning URIs.
+	EncodingBase32 SecretEncoding = iota
+	// EncodingHex decodes secrets like ParseHexSecret.
+	EncodingHex
+	// EncodingBase64 decodes secrets like ParseBase64Secret.
+	EncodingBase64
+	// EncodingRaw takes the bytes of the string as the secret.
+	EncodingRaw
+)
+
+// String returns the name of the secret encoding, e.g. "EncodingBase32".
+func (e SecretEncoding) String() string {
+	switch e {
+	case EncodingBase32:
+		return "EncodingBase32"
+	case EncodingHex:
+		return "EncodingHex"
+	case EncodingBase64:
+		return "EncodingBase64"
+	case EncodingRaw:
+		return "EncodingRaw"
+	}
+
+	return fmt.Sprintf("SecretEncoding(%d)", int(e))
+}
+
+// Decode decodes the secret with the lenient parsing rules of the encoding.
+func (e SecretEncoding) Decode(s string) (Secret, error) {
+	switch e {
+	case EncodingBase32:
+		return ParseBase32(s)
+	case EncodingHex:
+		return ParseHexSecret(s)
+	case EncodingBase64:
+		return ParseBase64Secret(s)
+	case EncodingRaw:
+		return Secret(s), nil
+	}
+
+	return nil, fmt.Errorf("%w, but was %v", ErrSecretEncoding, e)
+}
+
+// WithSecretEncoding configures the encoding of the string secrets taken by
+// GenerateString and ValidateString, or CodeString and ValidateAtString for
+// TOTP. It doesn't affect the methods taking the raw key. Default:
+// EncodingBase32.
+func WithSecretEncoding(e SecretEncoding) HotpOption {
+	return hotpOption(func(hp *hotp) {
+		hp.encoding = e
+	})
+}
+
+// GenerateString generates an OTP code like GenerateE for the secret encoded
+// with the configured secret encoding. Returns an error if the secret can't be
+// decoded. The decoded key is wiped after use.
+func (hp *hotp) GenerateString(secret string, counter Counter) (string, error) {
+	key, err := hp.encoding.Decode(secret)
+	if err != nil {
+		return "", err
+	}
+	defer key.Wipe()
+
+	return hp.GenerateE(key, counter)
+}
+
+// ValidateString validates an OTP code like Validate for the secret encoded
+// with the configured secret encoding. Returns an error if the secret can't be
+// decoded, the code is then rejected. The decoded key is wiped after use.
+func (hp *hotp) ValidateString(secret string, code string, counter Counter) (bool, error) {
+	key, err := hp.encoding.Decode(secret)
+	if err != nil {
+		hp.observe(false, 0)
+		return false, err
+	}
+	defer key.Wipe()
+
+	return hp.Validate(key, code, counter), nil
+}
+
+// CodeString generates a TOTP code like Code for the secret encoded with the
+// configured secret encoding. Returns an error if the secret can't be decoded
+// or the code can't be generated, like GenerateE. The decoded key is wiped
+// after use.
+func (tp *totp) CodeString(secret string, t time.Time) (string, error) {
+	return tp.hotp.GenerateString(secret, tp.At(t))
+}
+
+// ValidateAtString validates a TOTP code like ValidateAt for the secret encoded
+// with the configured secret encoding. Returns an error if the secret can't be
+// decoded, the code is then rejected. The decoded key is wiped after use.
+func (tp *totp) ValidateAtString(secret string, code string, t time.Time) (bool, error) {
+	key, err := tp.hotp.encoding.Decode(secret)
+	if err != nil {
+		tp.hotp.observe(false, 0)
+		return false, err
+	}
+	defer key.Wipe()
+
+	return tp.ValidateAt(key, code, t), nil
+}
@@ -0,0 +1,85 @@
+package otp
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestGenerateString(t *testing.T) {
+	testCases := []struct {
+		desc   string
+		hotp   *hotp
+		secret string
+	}{
+		{desc: "base32", hotp: NewHotp(), secret: "gezd gnbv gy3t qojq gezd gnbv gy3t qojq"},
+		{desc: "hex", hotp: NewHotp(WithSecretEncoding(EncodingHex)), secret: "3132333435363738393031323334353637383930"},
+		{desc: "base64", hotp: NewHotp(WithSecretEncoding(EncodingBase64)), secret: "MTIzNDU2Nzg5MDEyMzQ1Njc4OTA"},
+		{desc: "raw", hotp: NewHotp(WithSecretEncoding(EncodingRaw)), secret: "12345678901234567890"},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			if code, err := tC.hotp.GenerateString(tC.secret, 1); err != nil || code != "287082" {
+				t.Logf("Expected %s, but was %s (%v)", "287082", code, err)
+				t.Fail()
+			}
+			if valid, err := tC.hotp.ValidateString(tC.secret, "287082", 1); err != nil || !valid {
+				t.Logf("Code %s expected to be valid (%v)", "287082", err)
+				t.Fail()
+			}
+			if valid, err := tC.hotp.ValidateString(tC.secret, "287083", 1); err != nil || valid {
+				t.Logf("Code %s expected to be invalid (%v)", "287083", err)
+				t.Fail()
+			}
+		})
+	}
+}
+
+func TestCodeString(t *testing.T) {
+	at := time.Unix(59, 0)
+	totp := NewTotp(WithSecretEncoding(EncodingHex))
+	secret := "3132333435363738393031323334353637383930"
+	if code, err := totp.CodeString(secret, at); err != nil || code != "287082" {
+		t.Logf("Expected %s, but was %s (%v)", "287082", code, err)
+		t.Fail()
+	}
+	if valid, err := totp.ValidateAtString(secret, "287082", at); err != nil || !valid {
+		t.Logf("Code %s expected to be valid (%v)", "287082", err)
+		t.Fail()
+	}
+	if valid, err := NewTotp().ValidateAtString("GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ", "287082", at); err != nil || !valid {
+		t.Logf("Code %s expected to be valid with a Base32 secret (%v)", "287082", err)
+		t.Fail()
+	}
+	if valid, err := totp.ValidateAtString("GEZDGNBV", "287082", at); err == nil || valid {
+		t.Logf("Expected an error for an invalid hex secret, but was (%t, %v)", valid, err)
+		t.Fail()
+	}
+	if _, err := totp.CodeString("GEZDGNBV", at); err == nil {
+		t.Log("Expected an error for an invalid hex secret")
+		t.Fail()
+	}
+}
+
+func TestGenerateStringInvalid(t *testing.T) {
+	if _, err := NewHotp(WithSecretEncoding(EncodingHex)).GenerateString("GEZDGNBV", 1); err == nil {
+		t.Log("Expected an error for an invalid hex secret")
+		t.Fail()
+	}
+	if valid, err := NewHotp().ValidateString("GEZ1", "287082", 1); err == nil || valid {
+		t.Logf("Expected an error for an invalid base32 secret, but was (%t, %v)", valid, err)
+		t.Fail()
+	}
+	if _, err := NewHotpE(WithSecretEncoding(SecretEncoding(4))); !errors.Is(err, ErrSecretEncoding) {
+		t.Logf("Expected %v, but was %v", ErrSecretEncoding, err)
+		t.Fail()
+	}
+	if _, err := SecretEncoding(-1).Decode("GEZDGNBV"); !errors.Is(err, ErrSecretEncoding) {
+		t.Logf("Expected %v, but was %v", ErrSecretEncoding, err)
+		t.Fail()
+	}
+	if encoding := NewHotp(WithSecretEncoding(SecretEncoding(4))).encoding; encoding != EncodingBase32 {
+		t.Logf("Expected %v, but was %v", EncodingBase32, encoding)
+		t.Fail()
+	}
+}
@@ -0,0 +1,57 @@
+package otp
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"errors"
+	"fmt"
+	"hash"
+	"strings"
+)
+
+// ErrUnknownHash is returned for hash algorithm names without a known hash
+// function.
+var ErrUnknownHash = errors.New("otp: unknown hash algorithm")
+
+type algorithm struct {
+	name     string
+	hashFunc func() hash.Hash
+}
+
+// algorithms are the named hash functions. Authenticator apps support only
+// SHA1, SHA256 and SHA512.
+var algorithms = []algorithm{
+	{name: "SHA1", hashFunc: sha1.New},
+	{name: "SHA256", hashFunc: sha256.New},
+	{name: "SHA512", hashFunc: sha512.New},
+}
+
+// HashByName returns the hash function of the algorithm name used in otpauth
+// URIs: SHA1, SHA256 or SHA512, and SHA3-224, SHA3-256, SHA3-384 or SHA3-512
+// when built with Go 1.24 or later. Names are matched case-insensitively.
+func HashByName(name string) (func() hash.Hash, error) {
+	for _, alg := range algorithms {
+		if strings.EqualFold(name, alg.name) {
+			return alg.hashFunc, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w, but was %q", ErrUnknownHash, name)
+}
+
+// HashName returns the algorithm name of the hash function as used in otpauth
+// URIs. The hash function is identified by its digest of empty input, so any
+// constructor of a known algorithm is recognized. Returns an empty string for
+// unknown algorithms.
+func HashName(f func() hash.Hash) string {
+	sum := f().Sum(nil)
+	for _, alg := range algorithms {
+		if bytes.Equal(sum, alg.hashFunc().Sum(nil)) {
+			return alg.name
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,18 @@
+//go:build go1.24
+
+package otp
+
+import (
+	"crypto/sha3"
+	"hash"
+)
+
+// SHA3 hash functions come with the standard library since Go 1.24.
+func init() {
+	algorithms = append(algorithms,
+		algorithm{name: "SHA3-224", hashFunc: func() hash.Hash { return sha3.New224() }},
+		algorithm{name: "SHA3-256", hashFunc: func() hash.Hash { return sha3.New256() }},
+		algorithm{name: "SHA3-384", hashFunc: func() hash.Hash { return sha3.New384() }},
+		algorithm{name: "SHA3-512", hashFunc: func() hash.Hash { return sha3.New512() }},
+	)
+}
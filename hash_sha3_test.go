@@ -0,0 +1,63 @@
+//go:build go1.24
+
+package otp
+
+import (
+	"crypto/sha3"
+	"encoding/json"
+	"hash"
+	"testing"
+	"time"
+)
+
+func TestSha3(t *testing.T) {
+	key32 := []byte("12345678901234567890123456789012")
+	testCases := []struct {
+		name     string
+		hashFunc func() hash.Hash
+	}{
+		{name: "SHA3-224", hashFunc: func() hash.Hash { return sha3.New224() }},
+		{name: "SHA3-256", hashFunc: func() hash.Hash { return sha3.New256() }},
+		{name: "SHA3-384", hashFunc: func() hash.Hash { return sha3.New384() }},
+		{name: "SHA3-512", hashFunc: func() hash.Hash { return sha3.New512() }},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			if name := HashName(tC.hashFunc); name != tC.name {
+				t.Logf("Expected %s, but was %s", tC.name, name)
+				t.Fail()
+			}
+			if _, err := HashByName(tC.name); err != nil {
+				t.Logf("Expected no error, but was %v", err)
+				t.Fail()
+			}
+			totp := NewTotp(WithHash(tC.hashFunc), WithDigits(8))
+			now := time.Unix(1111111109, 0)
+			code := totp.Code(key32, now)
+			if !totp.ValidateAt(key32, code, now) {
+				t.Logf("Code %s expected to be valid", code)
+				t.Fail()
+			}
+			data, err := json.Marshal(totp)
+			if err != nil {
+				t.Logf("Expected no error, but was %v", err)
+				t.FailNow()
+			}
+			parsed := NewTotp()
+			if err := json.Unmarshal(data, parsed); err != nil {
+				t.Logf("Expected no error, but was %v", err)
+				t.FailNow()
+			}
+			if parsed.Code(key32, now) != code {
+				t.Logf("Expected code %s after round-trip, but was %s", code, parsed.Code(key32, now))
+				t.Fail()
+			}
+			uri := totp.URI("Example", "alice", key32)
+			parsed, _, err = ParseTotpURI(uri)
+			if err != nil || parsed.Code(key32, now) != code {
+				t.Logf("Expected code %s from %s (%v)", code, uri, err)
+				t.Fail()
+			}
+		})
+	}
+}
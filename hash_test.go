@@ -0,0 +1,64 @@
+package otp
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"errors"
+	"hash"
+	"testing"
+)
+
+func TestHashByName(t *testing.T) {
+	testCases := []struct {
+		name     string
+		expected string
+	}{
+		{name: "SHA1", expected: "SHA1"},
+		{name: "sha256", expected: "SHA256"},
+		{name: "Sha512", expected: "SHA512"},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.name, func(t *testing.T) {
+			f, err := HashByName(tC.name)
+			if err != nil {
+				t.Logf("Expected no error, but was %v", err)
+				t.FailNow()
+			}
+			if name := HashName(f); name != tC.expected {
+				t.Logf("Expected %s, but was %s", tC.expected, name)
+				t.Fail()
+			}
+		})
+	}
+}
+
+func TestHashByNameUnknown(t *testing.T) {
+	for _, name := range []string{"", "MD5", "SHA-1"} {
+		if _, err := HashByName(name); !errors.Is(err, ErrUnknownHash) {
+			t.Logf("Expected %v for %q, but was %v", ErrUnknownHash, name, err)
+			t.Fail()
+		}
+	}
+}
+
+func TestHashName(t *testing.T) {
+	testCases := []struct {
+		hashFunc func() hash.Hash
+		expected string
+	}{
+		{hashFunc: sha1.New, expected: "SHA1"},
+		{hashFunc: sha256.New, expected: "SHA256"},
+		{hashFunc: sha512.New, expected: "SHA512"},
+		{hashFunc: func() hash.Hash { return sha256.New() }, expected: "SHA256"},
+		{hashFunc: md5.New, expected: ""},
+		{hashFunc: sha512.New384, expected: ""},
+	}
+	for _, tC := range testCases {
+		if name := HashName(tC.hashFunc); name != tC.expected {
+			t.Logf("Expected %q, but was %q", tC.expected, name)
+			t.Fail()
+		}
+	}
+}
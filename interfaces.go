@@ -0,0 +1,35 @@
+package otp
+
+import "time"
+
+// Generator generates counter-based OTP codes. Generator is implemented by
+// HOTP instances.
+type Generator interface {
+	Generate(key []byte, counter Counter) string
+}
+
+// TimeGenerator generates time-based OTP codes. TimeGenerator is implemented
+// by TOTP instances.
+type TimeGenerator interface {
+	Code(key []byte, t time.Time) string
+}
+
+// Validator validates counter-based OTP codes without generating them, for
+// code paths that must only verify codes. Validator is implemented by HOTP
+// instances.
+type Validator interface {
+	Validate(key []byte, code string, counter Counter) bool
+}
+
+// TimeValidator validates time-based OTP codes without generating them.
+// TimeValidator is implemented by TOTP instances.
+type TimeValidator interface {
+	ValidateAt(key []byte, code string, t time.Time) bool
+}
+
+var (
+	_ Generator     = (*hotp)(nil)
+	_ TimeGenerator = (*totp)(nil)
+	_ Validator     = (*hotp)(nil)
+	_ TimeValidator = (*totp)(nil)
+)
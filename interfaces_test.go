@@ -0,0 +1,37 @@
+package otp
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGenerators(t *testing.T) {
+	key20 := []byte("12345678901234567890")
+	totp := NewTotp()
+	generators := []Generator{NewHotp(), NewHotp(WithDigits(8))}
+	for _, g := range generators {
+		if code := g.Generate(key20, 1); code[len(code)-6:] != "287082" {
+			t.Logf("Expected code ending with %s, but was %s", "287082", code)
+			t.Fail()
+		}
+	}
+	var tg TimeGenerator = totp
+	if code := tg.Code(key20, time.Unix(59, 0)); code != "287082" {
+		t.Logf("Expected code %s, but was %s", "287082", code)
+		t.Fail()
+	}
+}
+
+func TestValidators(t *testing.T) {
+	key20 := []byte("12345678901234567890")
+	var v Validator = NewHotp()
+	if !v.Validate(key20, "287082", 1) {
+		t.Logf("Code %s expected to be valid", "287082")
+		t.Fail()
+	}
+	var tv TimeValidator = NewTotp()
+	if !tv.ValidateAt(key20, "287082", time.Unix(59, 0)) {
+		t.Logf("Code %s expected to be valid", "287082")
+		t.Fail()
+	}
+}
@@ -0,0 +1,195 @@
+package otp
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// hotpConfig is the JSON form of the HOTP configuration.
+type hotpConfig struct {
+	Digits           int            `json:"digits"`
+	Algorithm        string         `json:"algorithm"`
+	Alphabet         string         `json:"alphabet,omitempty"`
+	LsbFirst         bool           `json:"lsbFirst,omitempty"`
+	Checksum         bool           `json:"checksum,omitempty"`
+	Lenient          bool           `json:"lenient,omitempty"`
+	NumericCompare   bool           `json:"numericCompare,omitempty"`
+	Strict           bool           `json:"strict,omitempty"`
+	TruncationOffset *int           `json:"truncationOffset,omitempty"`
+	Extended         bool           `json:"extendedTruncation,omitempty"`
+	LittleEndian     bool           `json:"littleEndian,omitempty"`
+	Modulus          int            `json:"modulus,omitempty"`
+	Throttle         int            `json:"throttle,omitempty"`
+	NoResync         bool           `json:"noResync,omitempty"`
+	SecretEncoding   SecretEncoding `json:"secretEncoding,omitempty"`
+}
+
+// totpConfig is the JSON form of the TOTP configuration.
+type totpConfig struct {
+	hotpConfig
+	Period   string   `json:"period"`
+	Epoch    Counter  `json:"epoch,omitempty"`
+	Skew     int      `json:"skew"`
+	Rounding Rounding `json:"rounding,omitempty"`
+}
+
+// MarshalJSON encodes the HOTP configuration as JSON, with the hash function
+// identified by its HashName. Secret keys, counters, the label, the observer
+// and the attempt limiter are not part of the configuration. Returns
+// ErrUnknownHash for hash functions without a name.
+func (hp *hotp) MarshalJSON() ([]byte, error) {
+	c, err := hp.config()
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(c)
+}
+
+// UnmarshalJSON configures the HOTP instance from the JSON produced by
+// MarshalJSON. Missing fields take the default values, the label, the observer
+// and the attempt limiter of the instance are kept. Returns an error for out
+// of range values like NewHotpE.
+func (hp *hotp) UnmarshalJSON(data []byte) error {
+	c, _ := defaultHotp().config()
+	if err := json.Unmarshal(data, &c); err != nil {
+		return err
+	}
+	parsed, err := c.hotp()
+	if err != nil {
+		return err
+	}
+	parsed.label = hp.label
+	parsed.observer = hp.observer
+	parsed.limiter = hp.limiter
+	*hp = *parsed
+
+	return nil
+}
+
+// MarshalJSON encodes the TOTP configuration as JSON like the HOTP one, plus
+// the period, epoch, skew and rounding. The period is written as a duration
+// string, e.g. "30s". The clock, the replay guard, the label, the observer and
+// the attempt limiter are not part of the configuration.
+func (tp *totp) MarshalJSON() ([]byte, error) {
+	c, err := tp.hotp.config()
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(totpConfig{
+		hotpConfig: c,
+		Period:     tp.timeStep.String(),
+		Epoch:      tp.epoch,
+		Skew:       tp.skew,
+		Rounding:   tp.rounding,
+	})
+}
+
+// UnmarshalJSON configures the TOTP instance from the JSON produced by
+// MarshalJSON. Missing fields take the default values, the clock, the replay
+// guard, the label, the observer and the attempt limiter of the instance are
+// kept.
+// Returns an error for out of range values like NewTotpE.
+func (tp *totp) UnmarshalJSON(data []byte) error {
+	defaults := defaultTotp()
+	hc, _ := defaults.hotp.config()
+	c := totpConfig{hotpConfig: hc, Period: defaults.timeStep.String(), Skew: defaults.skew}
+	if err := json.Unmarshal(data, &c); err != nil {
+		return err
+	}
+	hp, err := c.hotp()
+	if err != nil {
+		return err
+	}
+	step, err := time.ParseDuration(c.Period)
+	if err != nil {
+		return fmt.Errorf("otp: invalid period %q", c.Period)
+	}
+	parsed := defaults
+	parsed.hotp = *hp
+	parsed.timeStep = step
+	parsed.epoch = c.Epoch
+	parsed.skew = c.Skew
+	parsed.rounding = c.Rounding
+	if err := parsed.validate(); err != nil {
+		return err
+	}
+	if tp.clock != nil {
+		parsed.clock = tp.clock
+	}
+	parsed.replay = tp.replay
+	parsed.hotp.label = tp.hotp.label
+	parsed.hotp.observer = tp.hotp.observer
+	parsed.hotp.limiter = tp.hotp.limiter
+	*tp = *parsed
+
+	return nil
+}
+
+func (hp *hotp) config() (hotpConfig, error) {
+	name := HashName(hp.hashFunc)
+	if name == "" {
+		return hotpConfig{}, fmt.Errorf("%w, cannot encode a custom hash function", ErrUnknownHash)
+	}
+	c := hotpConfig{
+		Digits:         hp.digits,
+		Algorithm:      name,
+		LsbFirst:       hp.lsbFirst,
+		Checksum:       hp.checksum,
+		Lenient:        hp.lenient,
+		NumericCompare: hp.numeric,
+		Strict:         hp.strict,
+		Extended:       hp.extended,
+		LittleEndian:   hp.counterOrder == binary.LittleEndian,
+		Modulus:        hp.modulus,
+		Throttle:       hp.throttle,
+		NoResync:       hp.noResync,
+		SecretEncoding: hp.encoding,
+	}
+	if hp.alphabet != decimalAlphabet {
+		c.Alphabet = hp.alphabet
+	}
+	if hp.truncationOffset >= 0 {
+		offset := hp.truncationOffset
+		c.TruncationOffset = &offset
+	}
+
+	return c, nil
+}
+
+func (c hotpConfig) hotp() (*hotp, error) {
+	f, err := HashByName(c.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+	hp := defaultHotp()
+	hp.digits = c.Digits
+	hp.hashFunc = f
+	if c.Alphabet != "" {
+		hp.alphabet = c.Alphabet
+	}
+	hp.lsbFirst = c.LsbFirst
+	hp.checksum = c.Checksum
+	hp.lenient = c.Lenient
+	hp.numeric = c.NumericCompare
+	hp.strict = c.Strict
+	if c.LittleEndian {
+		hp.counterOrder = binary.LittleEndian
+	}
+	hp.modulus = c.Modulus
+	hp.throttle = c.Throttle
+	hp.noResync = c.NoResync
+	hp.extended = c.Extended
+	hp.encoding = c.SecretEncoding
+	if c.TruncationOffset != nil {
+		hp.truncationOffset = *c.TruncationOffset
+	}
+	if err := hp.validate(); err != nil {
+		return nil, err
+	}
+
+	return hp, nil
+}
@@ -0,0 +1,151 @@
+package otp
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestHotpJSON(t *testing.T) {
+	key20 := []byte("12345678901234567890")
+	testCases := []struct {
+		desc     string
+		hotp     *hotp
+		expected string
+	}{
+		{
+			desc:     "default",
+			hotp:     NewHotp(),
+			expected: `{"digits":6,"algorithm":"SHA1"}`,
+		},
+		{
+			desc:     "custom",
+			hotp:     NewHotp(WithDigits(8), WithHash(sha256.New), WithChecksum(true), WithTruncationOffset(0), WithModulus(1000)),
+			expected: `{"digits":8,"algorithm":"SHA256","checksum":true,"truncationOffset":0,"modulus":1000}`,
+		},
+		{
+			desc:     "validation",
+			hotp:     NewHotp(WithLenientInput(true), WithNumericCompare(true), WithStrictMode(true), WithCounterEndian(binary.LittleEndian)),
+			expected: `{"digits":6,"algorithm":"SHA1","lenient":true,"numericCompare":true,"strict":true,"littleEndian":true}`,
+		},
+		{
+			desc:     "alphabet",
+			hotp:     NewHotp(steamGuard()),
+			expected: `{"digits":5,"algorithm":"SHA1","alphabet":"23456789BCDFGHJKMNPQRTVWXY","lsbFirst":true}`,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			data, err := json.Marshal(tC.hotp)
+			if err != nil {
+				t.Logf("Expected no error, but was %v", err)
+				t.FailNow()
+			}
+			if string(data) != tC.expected {
+				t.Logf("Expected %s, but was %s", tC.expected, data)
+				t.Fail()
+			}
+			hp := NewHotp()
+			if err := json.Unmarshal(data, hp); err != nil {
+				t.Logf("Expected no error, but was %v", err)
+				t.FailNow()
+			}
+			for counter := Counter(0); counter < 10; counter++ {
+				if code, expected := hp.Generate(key20, counter), tC.hotp.Generate(key20, counter); code != expected {
+					t.Logf("Expected code %s for counter %d, but was %s", expected, counter, code)
+					t.Fail()
+				}
+			}
+		})
+	}
+}
+
+func TestTotpJSON(t *testing.T) {
+	key20 := []byte("12345678901234567890")
+	clock := fixedClock(time.Unix(1111111109, 0))
+	original := NewTotp(WithDigits(8), WithTimeStep(1500*time.Millisecond), WithEpoch(100), WithSkew(2))
+	data, err := json.Marshal(original)
+	if err != nil {
+		t.Logf("Expected no error, but was %v", err)
+		t.FailNow()
+	}
+	expected := `{"digits":8,"algorithm":"SHA1","period":"1.5s","epoch":100,"skew":2}`
+	if string(data) != expected {
+		t.Logf("Expected %s, but was %s", expected, data)
+		t.Fail()
+	}
+	tp := NewTotp(WithClock(clock))
+	if err := json.Unmarshal(data, tp); err != nil {
+		t.Logf("Expected no error, but was %v", err)
+		t.FailNow()
+	}
+	if tp.Now() != time.Time(clock) {
+		t.Logf("Expected the clock to be kept")
+		t.Fail()
+	}
+	now := time.Time(clock)
+	if code, expected := tp.Code(key20, now), original.Code(key20, now); code != expected {
+		t.Logf("Expected code %s, but was %s", expected, code)
+		t.Fail()
+	}
+	if ok, offset := tp.ValidateOffset(key20, original.Code(key20, now.Add(-3*time.Second)), now); !ok || offset != -2 {
+		t.Logf("Expected the skew to be kept, but was %v, %d", ok, offset)
+		t.Fail()
+	}
+}
+
+func TestTotpJSONDefaults(t *testing.T) {
+	tp := NewTotp(WithDigits(8))
+	if err := json.Unmarshal([]byte(`{}`), tp); err != nil {
+		t.Logf("Expected no error, but was %v", err)
+		t.FailNow()
+	}
+	if tp.hotp.digits != 6 || tp.timeStep != 30*time.Second {
+		t.Logf("Expected the defaults, but was %d digits and %v", tp.hotp.digits, tp.timeStep)
+		t.Fail()
+	}
+}
+
+func TestJSONLabel(t *testing.T) {
+	hp := NewHotp(WithLabel("alice"))
+	if err := json.Unmarshal([]byte(`{"digits":8}`), hp); err != nil || hp.String() != "HOTP(label=alice, digits=8, hash=SHA1)" {
+		t.Logf("Expected the label to be kept, but was %v (%v)", hp, err)
+		t.Fail()
+	}
+	tp := NewTotp(WithLabel("alice"))
+	if err := json.Unmarshal([]byte(`{"digits":8}`), tp); err != nil || tp.hotp.label != "alice" {
+		t.Logf("Expected the label to be kept, but was %v (%v)", tp, err)
+		t.Fail()
+	}
+}
+
+func TestJSONErrors(t *testing.T) {
+	if _, err := json.Marshal(NewHotp(WithHash(md5.New))); !errors.Is(err, ErrUnknownHash) {
+		t.Logf("Expected %v, but was %v", ErrUnknownHash, err)
+		t.Fail()
+	}
+	testCases := []struct {
+		data     string
+		expected error
+	}{
+		{data: `{"algorithm":"MD5"}`, expected: ErrUnknownHash},
+		{data: `{"digits":12}`, expected: ErrDigits},
+		{data: `{"alphabet":"A"}`, expected: ErrAlphabet},
+		{data: `{"period":"1us"}`, expected: ErrTimeStep},
+	}
+	for _, tC := range testCases {
+		tp := NewTotp()
+		if err := json.Unmarshal([]byte(tC.data), tp); !errors.Is(err, tC.expected) {
+			t.Logf("Expected %v for %s, but was %v", tC.expected, tC.data, err)
+			t.Fail()
+		}
+	}
+	if err := json.Unmarshal([]byte(`{"period":"soon"}`), NewTotp()); err == nil {
+		t.Logf("Expected an error for an invalid period")
+		t.Fail()
+	}
+}
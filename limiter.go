@@ -0,0 +1,104 @@
+package otp
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrRateLimited is returned when the attempt limiter denies a validation.
+var ErrRateLimited = errors.New("otp: too many attempts")
+
+// Limiter throttles validation attempts per key id to stop brute-forcing of
+// codes. Limiter must be safe for concurrent use.
+type Limiter interface {
+	// Allow reports whether a validation attempt for the id may proceed.
+	Allow(id string) bool
+	// Record records the result of a validation attempt for the id.
+	Record(id string, ok bool)
+}
+
+// WithAttemptLimiter configures the limiter consulted by the validations
+// keyed by an id: totp.ValidateOnce and StoredHotp.Verify. Denied attempts
+// fail with ErrRateLimited. Default: no limiter.
+func WithAttemptLimiter(l Limiter) HotpOption {
+	return hotpOption(func(hp *hotp) {
+		hp.limiter = l
+	})
+}
+
+// limit runs the validation for the id if the limiter allows it and records
+// its result.
+func (hp *hotp) limit(id string, validate func() (bool, error)) (bool, error) {
+	if hp.limiter == nil {
+		return validate()
+	}
+	if !hp.limiter.Allow(id) {
+		return false, ErrRateLimited
+	}
+	ok, err := validate()
+	if err == nil {
+		hp.limiter.Record(id, ok)
+	}
+
+	return ok, err
+}
+
+// MemoryLimiter is an in-memory Limiter for small deployments. It denies
+// attempts for an id after the given number of failures within a sliding
+// window. A successful attempt clears the failures of the id.
+type MemoryLimiter struct {
+	mu       sync.Mutex
+	max      int
+	window   time.Duration
+	failures map[string][]time.Time
+	now      func() time.Time
+}
+
+// NewMemoryLimiter creates a new MemoryLimiter allowing up to max failed
+// attempts per id within the window.
+func NewMemoryLimiter(max int, window time.Duration) *MemoryLimiter {
+	return &MemoryLimiter{
+		max:      max,
+		window:   window,
+		failures: make(map[string][]time.Time),
+		now:      time.Now,
+	}
+}
+
+// Allow reports whether the id has less than max failures within the window.
+func (l *MemoryLimiter) Allow(id string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return len(l.recent(id)) < l.max
+}
+
+// Record records a failure for the id, or clears its failures on success.
+func (l *MemoryLimiter) Record(id string, ok bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if ok {
+		delete(l.failures, id)
+		return
+	}
+	l.failures[id] = append(l.recent(id), l.now())
+}
+
+// recent drops the failures of the id that slid out of the window.
+func (l *MemoryLimiter) recent(id string) []time.Time {
+	failures := l.failures[id]
+	start := l.now().Add(-l.window)
+	i := 0
+	for i < len(failures) && !failures[i].After(start) {
+		i++
+	}
+	if i == len(failures) {
+		delete(l.failures, id)
+		return nil
+	}
+	failures = failures[i:]
+	l.failures[id] = failures
+
+	return failures
+}
@@ -0,0 +1,76 @@
+package otp
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMemoryLimiter(t *testing.T) {
+	now := time.Unix(1000, 0)
+	l := NewMemoryLimiter(3, time.Minute)
+	l.now = func() time.Time { return now }
+	for i := 0; i < 3; i++ {
+		if !l.Allow("alice") {
+			t.Logf("Expected attempt %d allowed", i+1)
+			t.Fail()
+		}
+		l.Record("alice", false)
+		now = now.Add(10 * time.Second)
+	}
+	if l.Allow("alice") {
+		t.Logf("Expected attempts denied after 3 failures")
+		t.Fail()
+	}
+	if !l.Allow("bob") {
+		t.Logf("Expected attempts of another id allowed")
+		t.Fail()
+	}
+	now = now.Add(31 * time.Second)
+	if !l.Allow("alice") {
+		t.Logf("Expected attempts allowed after the first failure slid out")
+		t.Fail()
+	}
+	l.Record("alice", false)
+	if l.Allow("alice") {
+		t.Logf("Expected attempts denied after another failure")
+		t.Fail()
+	}
+	l.Record("alice", true)
+	if !l.Allow("alice") {
+		t.Logf("Expected attempts allowed after a success")
+		t.Fail()
+	}
+}
+
+func TestAttemptLimiter(t *testing.T) {
+	key20 := []byte("12345678901234567890")
+	now := time.Now()
+	l := NewMemoryLimiter(2, time.Minute)
+	totp := NewTotp(WithAttemptLimiter(l))
+	code := totp.Code(key20, now)
+	for i := 0; i < 2; i++ {
+		if ok, err := totp.ValidateOnce("alice", key20, "000000", now); ok || err != nil {
+			t.Logf("Expected a failed attempt, but was %t, %v", ok, err)
+			t.Fail()
+		}
+	}
+	if ok, err := totp.ValidateOnce("alice", key20, code, now); ok || !errors.Is(err, ErrRateLimited) {
+		t.Logf("Expected %v, but was %t, %v", ErrRateLimited, ok, err)
+		t.Fail()
+	}
+	store := NewMemoryCounterStore()
+	store.Set("bob", 0)
+	sh := NewStoredHotp(NewHotp(WithAttemptLimiter(l)), store)
+	sh.Verify("bob", key20, "000000", 1)
+	if ok, err := sh.Verify("bob", key20, "755224", 1); !ok || err != nil {
+		t.Logf("Expected a successful attempt, but was %t, %v", ok, err)
+		t.Fail()
+	}
+	sh.Verify("bob", key20, "000000", 1)
+	sh.Verify("bob", key20, "000000", 1)
+	if ok, err := sh.Verify("bob", key20, "287082", 1); ok || !errors.Is(err, ErrRateLimited) {
+		t.Logf("Expected %v, but was %t, %v", ErrRateLimited, ok, err)
+		t.Fail()
+	}
+}
@@ -0,0 +1,132 @@
+package otp
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"hash"
+	"reflect"
+	"sync"
+)
+
+// generatorPools recycle the generators of the standard hash functions, so
+// codes are generated without allocating new hash states for every key. The
+// pools are keyed by the code pointer of the hash constructor, which only
+// identifies functions without captured variables like these.
+var generatorPools = map[uintptr]*sync.Pool{
+	reflect.ValueOf(sha1.New).Pointer():   newGeneratorPool(sha1.New),
+	reflect.ValueOf(sha256.New).Pointer(): newGeneratorPool(sha256.New),
+	reflect.ValueOf(sha512.New).Pointer(): newGeneratorPool(sha512.New),
+}
+
+func newGeneratorPool(f func() hash.Hash) *sync.Pool {
+	p := &sync.Pool{}
+	p.New = func() any {
+		return &generator{mac: newPooledMAC(f), pool: p}
+	}
+
+	return p
+}
+
+// rekey switches the generator to another key.
+func (g *generator) rekey(key []byte) {
+	g.err = g.hp.checkKey(key)
+	g.used = false
+	if mac, ok := g.mac.(*pooledMAC); ok {
+		mac.setKey(key)
+		return
+	}
+	g.mac = hmac.New(g.hp.hashFunc, key)
+}
+
+// release returns a pooled generator to its pool, wiping the key material.
+// The generator and the digests it returned must not be used afterwards.
+func (g *generator) release() {
+	if g.pool == nil {
+		return
+	}
+	g.mac.(*pooledMAC).wipe()
+	g.digest = [64]byte{}
+	g.hp = nil
+	g.err = nil
+	g.pool.Put(g)
+}
+
+// pooledMAC computes HMAC as specified by RFC 2104 like crypto/hmac, but can
+// be keyed again without allocating new hash states.
+type pooledMAC struct {
+	inner hash.Hash
+	outer hash.Hash
+	ipad  []byte
+	opad  []byte
+	// scratch holds the inner digest, and the hash of keys longer than a block
+	scratch [64]byte
+}
+
+func newPooledMAC(f func() hash.Hash) *pooledMAC {
+	inner, outer := f(), f()
+
+	return &pooledMAC{
+		inner: inner,
+		outer: outer,
+		ipad:  make([]byte, inner.BlockSize()),
+		opad:  make([]byte, outer.BlockSize()),
+	}
+}
+
+// setKey keys the MAC and resets it.
+func (m *pooledMAC) setKey(key []byte) {
+	if len(key) > len(m.ipad) {
+		m.outer.Reset()
+		m.outer.Write(key)
+		key = m.outer.Sum(m.scratch[:0])
+	}
+	for i := range m.ipad {
+		m.ipad[i] = 0
+	}
+	copy(m.ipad, key)
+	copy(m.opad, m.ipad)
+	for i := range m.ipad {
+		m.ipad[i] ^= 0x36
+		m.opad[i] ^= 0x5c
+	}
+	m.Reset()
+}
+
+// wipe overwrites the key material with zeros.
+func (m *pooledMAC) wipe() {
+	for i := range m.ipad {
+		m.ipad[i] = 0
+		m.opad[i] = 0
+	}
+	m.scratch = [64]byte{}
+	m.inner.Reset()
+	m.outer.Reset()
+}
+
+func (m *pooledMAC) Write(p []byte) (int, error) {
+	return m.inner.Write(p)
+}
+
+func (m *pooledMAC) Sum(b []byte) []byte {
+	in := m.inner.Sum(m.scratch[:0])
+	m.outer.Reset()
+	m.outer.Write(m.opad)
+	m.outer.Write(in)
+
+	return m.outer.Sum(b)
+}
+
+func (m *pooledMAC) Reset() {
+	m.inner.Reset()
+	m.inner.Write(m.ipad)
+}
+
+func (m *pooledMAC) Size() int {
+	return m.outer.Size()
+}
+
+func (m *pooledMAC) BlockSize() int {
+	return m.inner.BlockSize()
+}
@@ -0,0 +1,50 @@
+package otp
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"hash"
+	"testing"
+)
+
+func TestPooledMAC(t *testing.T) {
+	msg := []byte("counter!")
+	for _, f := range []func() hash.Hash{sha1.New, sha256.New, sha512.New} {
+		mac := newPooledMAC(f)
+		for _, size := range []int{0, 20, 64, 65, 128, 129, 200} {
+			key := bytes.Repeat([]byte{0xa5}, size)
+			mac.setKey(key)
+			expected := hmac.New(f, key)
+			expected.Write(msg)
+			mac.Write(msg)
+			if sum := mac.Sum(nil); !hmac.Equal(sum, expected.Sum(nil)) {
+				t.Logf("Expected %x for a %d byte key, but was %x", expected.Sum(nil), size, sum)
+				t.Fail()
+			}
+			mac.Reset()
+			mac.Write(msg)
+			if sum := mac.Sum(nil); !hmac.Equal(sum, expected.Sum(nil)) {
+				t.Logf("Expected %x after reset, but was %x", expected.Sum(nil), sum)
+				t.Fail()
+			}
+		}
+	}
+}
+
+func TestGeneratorRelease(t *testing.T) {
+	key20 := []byte("12345678901234567890")
+	g := NewHotp().generator(key20)
+	mac := g.mac.(*pooledMAC)
+	g.release()
+	if !bytes.Equal(mac.ipad, make([]byte, len(mac.ipad))) || g.digest != [64]byte{} {
+		t.Log("Expected the key material to be wiped on release")
+		t.Fail()
+	}
+	if _, ok := NewHotp(WithHash(shortSHA1(20))).generator(key20).mac.(*pooledMAC); ok {
+		t.Log("Expected custom hash functions not to be pooled")
+		t.Fail()
+	}
+}
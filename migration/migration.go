@@ -0,0 +1,231 @@
+// Package migration decodes the otpauth-migration:// URIs exported by Google
+// Authenticator, which carry several accounts in a single QR code. The
+// payload is a protocol buffer message, decoded here without dependencies.
+//
+// Example usage:
+//
+//	import (
+//		"github.com/sshilin/otp"
+//		"github.com/sshilin/otp/migration"
+//	)
+//
+//	accounts, err := migration.ParseMigration(uri)
+//	for _, account := range accounts {
+//		u, err := account.URI()
+//		totp, key, err := otp.ParseTotpURI(u)
+//	}
+package migration
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/sshilin/otp"
+)
+
+// ErrInvalidPayload is returned for a migration payload that isn't a valid
+// protocol buffer message.
+var ErrInvalidPayload = errors.New("migration: invalid payload")
+
+// Account holds the parameters of a single exported account.
+type Account struct {
+	Secret otp.Secret
+	// Name is the account name, often prefixed with the issuer and a colon
+	Name   string
+	Issuer string
+	// Algorithm is the hash name: "SHA1", "SHA256", "SHA512" or "MD5"
+	Algorithm string
+	// Digits is the code length: 6 or 8
+	Digits int
+	// Type is the OTP type: "totp" or "hotp"
+	Type string
+	// Counter is the next counter value of HOTP accounts
+	Counter otp.Counter
+}
+
+// Field numbers of the MigrationPayload.OtpParameters message.
+const (
+	fieldSecret    = 1
+	fieldName      = 2
+	fieldIssuer    = 3
+	fieldAlgorithm = 4
+	fieldDigits    = 5
+	fieldType      = 6
+	fieldCounter   = 7
+)
+
+// Wire types of protocol buffer fields.
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+// algorithms maps the Algorithm enum to hash names, 0 being unspecified.
+var algorithms = [...]string{"SHA1", "SHA1", "SHA256", "SHA512", "MD5"}
+
+// ParseMigration parses an otpauth-migration://offline?data=... URI and
+// returns the exported accounts. Unspecified algorithms, digits and types
+// default to SHA1, 6 digits and TOTP like in Google Authenticator. Large
+// exports are split into several URIs, each to be parsed on its own.
+func ParseMigration(uri string) ([]Account, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, fmt.Errorf("migration: invalid URI: %w", err)
+	}
+	if u.Scheme != "otpauth-migration" || u.Host != "offline" {
+		return nil, fmt.Errorf("migration: unsupported URI %q", u.Scheme+"://"+u.Host)
+	}
+	// Unescaped "+" of the Base64 data decode to spaces in the query.
+	data := strings.ReplaceAll(u.Query().Get("data"), " ", "+")
+	if data == "" {
+		return nil, errors.New("migration: missing data")
+	}
+	payload, err := base64.RawStdEncoding.DecodeString(strings.TrimRight(data, "="))
+	if err != nil {
+		return nil, fmt.Errorf("migration: invalid data: %w", err)
+	}
+	var accounts []Account
+	err = fields(payload, func(num int, value uint64, b []byte) error {
+		if num != 1 || b == nil {
+			return nil
+		}
+		account, err := parseAccount(b)
+		if err != nil {
+			return err
+		}
+		accounts = append(accounts, account)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return accounts, nil
+}
+
+// parseAccount decodes an OtpParameters message.
+func parseAccount(msg []byte) (Account, error) {
+	account := Account{Algorithm: "SHA1", Digits: 6, Type: "totp"}
+	err := fields(msg, func(num int, value uint64, b []byte) error {
+		switch num {
+		case fieldSecret:
+			account.Secret = append(otp.Secret(nil), b...)
+		case fieldName:
+			account.Name = string(b)
+		case fieldIssuer:
+			account.Issuer = string(b)
+		case fieldAlgorithm:
+			if value >= uint64(len(algorithms)) {
+				return fmt.Errorf("%w, unknown algorithm %d", ErrInvalidPayload, value)
+			}
+			account.Algorithm = algorithms[value]
+		case fieldDigits:
+			if value == 2 {
+				account.Digits = 8
+			}
+		case fieldType:
+			if value == 1 {
+				account.Type = "hotp"
+			}
+		case fieldCounter:
+			account.Counter = otp.Counter(value)
+		}
+
+		return nil
+	})
+
+	return account, err
+}
+
+// fields calls f for each field of the protocol buffer message, with the
+// value of varint fields or the bytes of length-delimited fields. Fixed size
+// fields are skipped.
+func fields(msg []byte, f func(num int, value uint64, b []byte) error) error {
+	for len(msg) > 0 {
+		key, n := varint(msg)
+		if n == 0 {
+			return fmt.Errorf("%w, truncated field key", ErrInvalidPayload)
+		}
+		msg = msg[n:]
+		num := int(key >> 3)
+		switch key & 7 {
+		case wireVarint:
+			value, n := varint(msg)
+			if n == 0 {
+				return fmt.Errorf("%w, truncated varint of field %d", ErrInvalidPayload, num)
+			}
+			msg = msg[n:]
+			if err := f(num, value, nil); err != nil {
+				return err
+			}
+		case wireBytes:
+			length, n := varint(msg)
+			if n == 0 || length > uint64(len(msg)-n) {
+				return fmt.Errorf("%w, truncated bytes of field %d", ErrInvalidPayload, num)
+			}
+			b := msg[n : n+int(length)]
+			msg = msg[n+int(length):]
+			if err := f(num, 0, b); err != nil {
+				return err
+			}
+		case wireFixed64, wireFixed32:
+			size := 8
+			if key&7 == wireFixed32 {
+				size = 4
+			}
+			if len(msg) < size {
+				return fmt.Errorf("%w, truncated fixed field %d", ErrInvalidPayload, num)
+			}
+			msg = msg[size:]
+		default:
+			return fmt.Errorf("%w, unsupported wire type %d of field %d", ErrInvalidPayload, key&7, num)
+		}
+	}
+
+	return nil
+}
+
+// varint decodes a base 128 varint and returns its value and length, or a
+// length of 0 if the data ends before the varint or it overflows 64 bits.
+func varint(b []byte) (uint64, int) {
+	var value uint64
+	for i := 0; i < len(b) && i < 10; i++ {
+		value |= uint64(b[i]&0x7f) << (7 * i)
+		if b[i] < 0x80 {
+			return value, i + 1
+		}
+	}
+
+	return 0, 0
+}
+
+// URI returns the otpauth:// provisioning URI of the account, to be parsed
+// with otp.ParseTotpURI or otp.ParseHotpURI depending on the type. The issuer
+// prefix is dropped from the name, as the URI carries the issuer separately.
+// Returns otp.ErrUnknownHash for algorithms the otp package doesn't support.
+func (a Account) URI() (string, error) {
+	f, err := otp.HashByName(a.Algorithm)
+	if err != nil {
+		return "", err
+	}
+	name := a.Name
+	if a.Issuer != "" {
+		name = strings.TrimPrefix(name, a.Issuer+":")
+	}
+	opts := []otp.HotpOption{otp.WithHash(f), otp.WithDigits(a.Digits)}
+	if a.Type == "hotp" {
+		return otp.NewHotp(opts...).URI(a.Issuer, name, a.Secret, a.Counter), nil
+	}
+	totpOpts := make([]otp.TotpOption, len(opts))
+	for i, opt := range opts {
+		totpOpts[i] = opt
+	}
+
+	return otp.NewTotp(totpOpts...).URI(a.Issuer, name, a.Secret), nil
+}
@@ -0,0 +1,122 @@
+package migration
+
+import (
+	"bytes"
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/sshilin/otp"
+)
+
+func TestParseMigration(t *testing.T) {
+	uri := "otpauth-migration://offline?data=CjEKCkhlbGxvId6tvu8SGEV4YW1wbGU6YWxpY2VAZ29vZ2xlLmNvbRoHRXhhbXBsZTAC"
+	accounts, err := ParseMigration(uri)
+	if err != nil || len(accounts) != 1 {
+		t.Logf("Expected 1 account, but was %d (%v)", len(accounts), err)
+		t.FailNow()
+	}
+	expected := Account{
+		Secret:    otp.Secret("Hello!\xde\xad\xbe\xef"),
+		Name:      "Example:alice@google.com",
+		Issuer:    "Example",
+		Algorithm: "SHA1",
+		Digits:    6,
+		Type:      "totp",
+	}
+	account := accounts[0]
+	if !bytes.Equal(account.Secret, expected.Secret) || account.Name != expected.Name || account.Issuer != expected.Issuer ||
+		account.Algorithm != expected.Algorithm || account.Digits != expected.Digits || account.Type != expected.Type {
+		t.Logf("Expected %+v, but was %+v", expected, account)
+		t.Fail()
+	}
+	u, err := account.URI()
+	if err != nil {
+		t.Logf("Expected no error, but was %v", err)
+		t.FailNow()
+	}
+	expectedURI := "otpauth://totp/Example:alice@google.com?secret=JBSWY3DPEHPK3PXP&issuer=Example&algorithm=SHA1&digits=6&period=30"
+	if u != expectedURI {
+		t.Logf("Expected %s, but was %s", expectedURI, u)
+		t.Fail()
+	}
+	if _, key, err := otp.ParseTotpURI(u); err != nil || !bytes.Equal(key, expected.Secret) {
+		t.Logf("Expected the URI to parse, but was %v", err)
+		t.Fail()
+	}
+}
+
+func TestParseMigrationHotp(t *testing.T) {
+	// Two accounts with the batch fields set and an unknown fixed32 field
+	data := "CjkKFDEyMzQ1Njc4OTAxMjM0NTY3ODkwEg9ib2JAZXhhbXBsZS5jb20aB0FDTUUgQ28gAigCMAE4rAIKJAoUMTIzNDU2Nzg5MDEyMzQ1Njc4OTASBWNhcm9sMAJNAQIDBBABGAEgACj7//////////8B"
+	accounts, err := ParseMigration("otpauth-migration://offline?data=" + url.QueryEscape(data))
+	if err != nil || len(accounts) != 2 {
+		t.Logf("Expected 2 accounts, but was %d (%v)", len(accounts), err)
+		t.FailNow()
+	}
+	hotp := accounts[0]
+	if hotp.Type != "hotp" || hotp.Algorithm != "SHA256" || hotp.Digits != 8 || hotp.Counter != 300 || hotp.Issuer != "ACME Co" {
+		t.Logf("Unexpected account %+v", hotp)
+		t.Fail()
+	}
+	u, err := hotp.URI()
+	if err != nil {
+		t.Logf("Expected no error, but was %v", err)
+		t.FailNow()
+	}
+	_, key, counter, err := otp.ParseHotpURI(u)
+	if err != nil || string(key) != "12345678901234567890" || counter != 300 {
+		t.Logf("Expected the URI to parse, but was %v (counter %d)", err, counter)
+		t.Fail()
+	}
+	totp := accounts[1]
+	if totp.Type != "totp" || totp.Algorithm != "SHA1" || totp.Digits != 6 || totp.Name != "carol" {
+		t.Logf("Unexpected account %+v", totp)
+		t.Fail()
+	}
+	u, _ = totp.URI()
+	tp, key, err := otp.ParseTotpURI(u)
+	if err != nil {
+		t.Logf("Expected the URI to parse, but was %v", err)
+		t.FailNow()
+	}
+	if code := tp.Code(key, time.Unix(59, 0)); code != "287082" {
+		t.Logf("Expected %s, but was %s", "287082", code)
+		t.Fail()
+	}
+	// Unescaped "+" in the query decodes to a space
+	if accounts, err := ParseMigration("otpauth-migration://offline?data=" + data); err != nil || len(accounts) != 2 {
+		t.Logf("Expected 2 accounts from unescaped data, but was %d (%v)", len(accounts), err)
+		t.Fail()
+	}
+}
+
+func TestParseMigrationErrors(t *testing.T) {
+	testCases := []struct {
+		desc string
+		uri  string
+		err  error
+	}{
+		{desc: "truncated message", uri: "otpauth-migration://offline?data=CjEKCkhlbGxv", err: ErrInvalidPayload},
+		{desc: "truncated varint", uri: "otpauth-migration://offline?data=EA", err: ErrInvalidPayload},
+		{desc: "unknown algorithm", uri: "otpauth-migration://offline?data=CgIgCQ", err: ErrInvalidPayload},
+		{desc: "wrong scheme", uri: "otpauth://offline?data=CgIgAQ"},
+		{desc: "wrong host", uri: "otpauth-migration://online?data=CgIgAQ"},
+		{desc: "missing data", uri: "otpauth-migration://offline"},
+		{desc: "invalid base64", uri: "otpauth-migration://offline?data=%21%21"},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			_, err := ParseMigration(tC.uri)
+			if err == nil || tC.err != nil && !errors.Is(err, tC.err) {
+				t.Logf("Expected %v, but was %v", tC.err, err)
+				t.Fail()
+			}
+		})
+	}
+	if _, err := (Account{Algorithm: "MD5", Digits: 6, Type: "totp"}).URI(); !errors.Is(err, otp.ErrUnknownHash) {
+		t.Logf("Expected %v, but was %v", otp.ErrUnknownHash, err)
+		t.Fail()
+	}
+}
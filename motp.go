@@ -0,0 +1,60 @@
+package otp
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// motpDigits is the number of hex characters in a mOTP code.
+const motpDigits = 6
+
+type motp struct {
+	totp totp
+}
+
+// NewMotp creates a new instance for generating Mobile-OTP (mOTP) codes used
+// by legacy VPN setups. mOTP takes the first 6 hex characters of the MD5 hash
+// of the time step counter, the secret and the PIN, instead of an HMAC based
+// code. The time step defaults to 10 seconds and the skew to 1 step. Only the
+// WithTimeStep, WithEpoch and WithSkew options apply to mOTP.
+func NewMotp(opts ...TotpOption) *motp {
+	defaults := []TotpOption{WithTimeStep(10 * time.Second)}
+
+	return &motp{totp: *NewTotp(append(defaults, opts...)...)}
+}
+
+// Generate generates the mOTP code for the time step containing t from the
+// secret and the PIN.
+func (mp *motp) Generate(secret, pin string, t time.Time) string {
+	return mp.generate(secret, pin, mp.totp.At(t))
+}
+
+// Validate validates a mOTP code against the secret and the PIN at the given
+// time, accepting the time steps within the configured skew. Hex characters of
+// the code are matched case-insensitively. No code is valid at times before
+// the epoch.
+func (mp *motp) Validate(secret, pin, code string, t time.Time) bool {
+	code = strings.ToLower(code)
+	if len(code) != motpDigits || mp.totp.beforeEpoch(t) {
+		return false
+	}
+	counter := mp.totp.At(t)
+	found := 0
+	for offset := -mp.totp.skew; offset <= mp.totp.skew; offset++ {
+		if offset < 0 && Counter(-offset) > counter {
+			continue
+		}
+		found |= equal(code, mp.generate(secret, pin, counter+Counter(offset)))
+	}
+
+	return found == 1
+}
+
+func (mp *motp) generate(secret, pin string, counter Counter) string {
+	sum := md5.Sum([]byte(strconv.FormatUint(uint64(counter), 10) + secret + pin))
+
+	return hex.EncodeToString(sum[:])[:motpDigits]
+}
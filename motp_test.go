@@ -0,0 +1,67 @@
+package otp
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMotp(t *testing.T) {
+	secret, pin := "0123456789abcdef", "1234"
+	testCases := []struct {
+		time     int64
+		expected string
+	}{
+		{time: 59, expected: "3982c0"},
+		{time: 1111111109, expected: "063dcf"},
+		{time: 1234567890, expected: "f41e13"},
+	}
+	motp := NewMotp()
+	for _, tC := range testCases {
+		t.Run(tC.expected, func(t *testing.T) {
+			now := time.Unix(tC.time, 0)
+			code := motp.Generate(secret, pin, now)
+			if code != tC.expected {
+				t.Logf("Expected code %s, but was %s", tC.expected, code)
+				t.Fail()
+			}
+			if !motp.Validate(secret, pin, code, now) {
+				t.Logf("Code %s expected to be valid", code)
+				t.Fail()
+			}
+		})
+	}
+}
+
+func TestMotpValidate(t *testing.T) {
+	secret, pin := "0123456789abcdef", "1234"
+	now := time.Unix(1111111109, 0)
+	motp := NewMotp()
+	code := motp.Generate(secret, pin, now)
+	testCases := []struct {
+		desc     string
+		code     string
+		pin      string
+		time     time.Time
+		expected bool
+	}{
+		{desc: "uppercase", code: "063DCF", pin: pin, time: now, expected: true},
+		{desc: "previous step", code: code, pin: pin, time: now.Add(10 * time.Second), expected: true},
+		{desc: "next step", code: code, pin: pin, time: now.Add(-10 * time.Second), expected: true},
+		{desc: "outside skew", code: code, pin: pin, time: now.Add(20 * time.Second), expected: false},
+		{desc: "wrong pin", code: code, pin: "0000", time: now, expected: false},
+		{desc: "short code", code: code[:5], pin: pin, time: now, expected: false},
+		{desc: "before epoch", code: code, pin: pin, time: time.Unix(-1, 0), expected: false},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			if ok := motp.Validate(secret, tC.pin, tC.code, tC.time); ok != tC.expected {
+				t.Logf("Expected %v for code %s, but was %v", tC.expected, tC.code, ok)
+				t.Fail()
+			}
+		})
+	}
+	if NewMotp(WithSkew(0)).Validate(secret, pin, code, now.Add(10*time.Second)) {
+		t.Logf("Code %s expected to be invalid without skew", code)
+		t.Fail()
+	}
+}
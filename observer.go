@@ -0,0 +1,40 @@
+package otp
+
+// Observer is notified of the result of every validation, e.g. to export
+// metrics about attempts, failures and clock drifts. Observer must be safe for
+// concurrent use.
+type Observer interface {
+	// OnValidate is called after a code was validated. The offset is the
+	// distance of the matched counter value from the expected one: the signed
+	// time step offset for TOTP and the look-ahead distance for HOTP. The
+	// offset is 0 when nothing matched.
+	OnValidate(result bool, offset int)
+}
+
+// LabeledObserver is an Observer that is notified with the label configured by
+// WithLabel as well, e.g. to tell the tokens apart in metrics. OnValidateLabeled
+// is called instead of OnValidate.
+type LabeledObserver interface {
+	Observer
+	// OnValidateLabeled is called like OnValidate with the label of the
+	// instance, empty if not configured.
+	OnValidateLabeled(label string, result bool, offset int)
+}
+
+// WithObserver configures the observer notified of validation results. A nil
+// observer disables notifications. Default: no observer.
+func WithObserver(o Observer) HotpOption {
+	return hotpOption(func(hp *hotp) {
+		hp.observer = o
+	})
+}
+
+func (hp *hotp) observe(result bool, offset int) {
+	switch o := hp.observer.(type) {
+	case nil:
+	case LabeledObserver:
+		o.OnValidateLabeled(hp.label, result, offset)
+	default:
+		o.OnValidate(result, offset)
+	}
+}
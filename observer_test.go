@@ -0,0 +1,92 @@
+package otp
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+type validation struct {
+	result bool
+	offset int
+}
+
+type recordingObserver struct {
+	mu          sync.Mutex
+	validations []validation
+}
+
+func (o *recordingObserver) OnValidate(result bool, offset int) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.validations = append(o.validations, validation{result: result, offset: offset})
+}
+
+func TestObserver(t *testing.T) {
+	key20 := []byte("12345678901234567890")
+	o := &recordingObserver{}
+	hotp := NewHotp(WithObserver(o))
+	hotp.Validate(key20, "755224", 0)
+	hotp.Validate(key20, "755225", 0)
+	hotp.ValidateLookAhead(key20, "338314", 2, 5)
+	hotp.ValidateLookAhead(key20, "338314", 5, 5)
+	hotp.ValidateE(key20, "75522", 0)
+	totp := NewTotp(WithSkew(1), WithObserver(o))
+	totp.ValidateAt(key20, "287082", time.Unix(89, 0))
+	totp.ValidateAt(key20, "287082", time.Unix(-1, 0))
+	totp.ValidateAtE(key20, "28708x", time.Unix(59, 0))
+	expected := []validation{
+		{result: true, offset: 0},
+		{result: false, offset: 0},
+		{result: true, offset: 2},
+		{result: false, offset: 0},
+		{result: false, offset: 0},
+		{result: true, offset: -1},
+		{result: false, offset: 0},
+		{result: false, offset: 0},
+	}
+	if len(o.validations) != len(expected) {
+		t.Logf("Expected %v, but was %v", expected, o.validations)
+		t.FailNow()
+	}
+	for i := range expected {
+		if o.validations[i] != expected[i] {
+			t.Logf("Expected %v at %d, but was %v", expected[i], i, o.validations[i])
+			t.Fail()
+		}
+	}
+}
+
+func TestObserverDisabled(t *testing.T) {
+	key20 := []byte("12345678901234567890")
+	if !NewHotp(WithObserver(nil)).Validate(key20, "755224", 0) {
+		t.Logf("Code %s expected to be valid", "755224")
+		t.Fail()
+	}
+}
+
+type labeledObserver struct {
+	recordingObserver
+	labels []string
+}
+
+func (o *labeledObserver) OnValidateLabeled(label string, result bool, offset int) {
+	o.labels = append(o.labels, label)
+	o.OnValidate(result, offset)
+}
+
+func TestLabeledObserver(t *testing.T) {
+	key20 := []byte("12345678901234567890")
+	o := &labeledObserver{}
+	alice := NewHotp(WithLabel("alice"), WithObserver(o))
+	alice.Validate(key20, "755224", 0)
+	NewHotp(WithObserver(o)).Validate(key20, "755225", 0)
+	if len(o.labels) != 2 || o.labels[0] != "alice" || o.labels[1] != "" {
+		t.Logf("Expected labels %q, but was %q", []string{"alice", ""}, o.labels)
+		t.Fail()
+	}
+	if !alice.Equal(NewHotp()) || alice.Generate(key20, 0) != "755224" {
+		t.Log("Expected the label not to affect the codes")
+		t.Fail()
+	}
+}
@@ -19,141 +19,1663 @@
 //
 //	// Generate a TOTP code
 //	totp := NewTotp()
-//	code := hotp.Generate(key, totp.At(time.Now()))
+//	code := totp.Code(key, time.Now())
 //
 //	// Validate a TOTP code
-//	isValid := hotp.Validate(key, code, totp.At(time.Now()))
+//	isValid := totp.ValidateAt(key, code, time.Now())
 //
-//	// Validate a TOTP code one time step backward and forward
-//	// because of possible clock drifts between a client and a server
-//	isValid = hotp.Validate(key, code, totp.At(time.Now())-1)
-//	isValid = hotp.Validate(key, code, totp.At(time.Now())+1)
+//	// Codes are accepted one time step backward and forward by default,
+//	// because of possible clock drifts between a client and a server.
+//	// Validate a TOTP code of the exact time step only
+//	totp = NewTotp(WithSkew(0))
+//	isValid = totp.ValidateAt(key, code, time.Now())
 package otp
 
 import (
+	"context"
 	"crypto/hmac"
 	"crypto/sha1"
+	"crypto/subtle"
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"hash"
-	"math"
+	"io"
+	"math/bits"
+	"reflect"
+	"strings"
+	"sync"
 	"time"
 )
 
+const (
+	// minDigits is the shortest code allowed by RFC 4226.
+	minDigits = 6
+	// rfcDigits is the longest code of RFC 4226 tokens.
+	rfcDigits = 8
+	// narrowDigits is the longest decimal code rendered from the 31-bit value
+	// produced by the dynamic truncation of RFC 4226.
+	narrowDigits = 9
+	// maxDigits is the longest decimal code, rendered from the 63-bit value of
+	// the truncation widened to the 4 bytes following the RFC 4226 ones.
+	maxDigits = 10
+	// minTimeStep is the shortest supported TOTP time step.
+	minTimeStep = time.Millisecond
+	// decimalAlphabet holds the symbols of decimal codes.
+	decimalAlphabet = "0123456789"
+	// maxRangeSteps bounds the time steps scanned by ValidateInRange.
+	maxRangeSteps = 1000000
+	// maxSkew bounds the skew, so codes are never accepted for more than 21
+	// time steps, e.g. 10.5 minutes with the default 30 seconds step.
+	maxSkew = 10
+	// maxLookAhead bounds the HOTP look-ahead window, so validation scans at
+	// most 1001 counter values.
+	maxLookAhead = 1000
+)
+
+// ErrDigits is returned for a number of digits outside of the supported range.
+var ErrDigits = errors.New("otp: number of digits out of range")
+
+// ErrAlphabet is returned for an alphabet of less than 2 distinct ASCII symbols.
+var ErrAlphabet = errors.New("otp: alphabet must have at least 2 distinct ASCII symbols")
+
+// ErrTruncationOffset is returned for a truncation offset that doesn't leave 4
+// bytes to the end of the digest.
+var ErrTruncationOffset = errors.New("otp: truncation offset out of range")
+
+// ErrChecksum is returned for the checksum digit configured with non-decimal
+// codes.
+var ErrChecksum = errors.New("otp: checksum digit requires decimal codes")
+
+// ErrModulus is returned for a modulus smaller than 2 or larger than the number
+// of distinct codes of the configured length.
+var ErrModulus = errors.New("otp: modulus out of range")
+
+// ErrDigestSize is returned for hash functions with digests too short for the
+// dynamic truncation, which reads 4 bytes at an offset of up to 15.
+var ErrDigestSize = errors.New("otp: digest too short for truncation")
+
+// ErrTimeStep is returned for a TOTP time step shorter than 1 millisecond.
+var ErrTimeStep = errors.New("otp: time step must be at least 1ms")
+
+// ErrSkew is returned for a negative TOTP skew or a skew of more than 10 time
+// steps.
+var ErrSkew = errors.New("otp: skew out of range")
+
+// ErrWindow is returned for an HOTP look-ahead window of more than 1000 counter
+// values.
+var ErrWindow = errors.New("otp: look-ahead window out of range")
+
+// ErrCodeLength is returned for a code of the wrong length.
+var ErrCodeLength = errors.New("otp: wrong code length")
+
+// ErrCodeFormat is returned for a code with symbols outside of the alphabet or
+// a wrong checksum digit.
+var ErrCodeFormat = errors.New("otp: malformed code")
+
+// ErrCodeMismatch is returned for a well-formed code that doesn't match the
+// expected one.
+var ErrCodeMismatch = errors.New("otp: code mismatch")
+
+// ErrOutsideWindow is returned for a well-formed code that doesn't match any
+// counter value within the validation window.
+var ErrOutsideWindow = errors.New("otp: code outside of the validation window")
+
+// ErrRangeTooLarge is returned for a time range of more than 1000000 time
+// steps.
+var ErrRangeTooLarge = errors.New("otp: time range too large")
+
 // Counter represents the moving factor value used in RFC 4226 (HOTP) standard.
 // Counter must increment with each OTP generation to produce a unique code.
 type Counter uint64
 
 type hotp struct {
+	// label tags the instance in diagnostics, never mixed into the codes
+	label    string
 	digits   int
 	hashFunc func() hash.Hash
+	alphabet string
+	// lsbFirst renders the least significant symbol of the code first
+	lsbFirst bool
+	checksum bool
+	lenient  bool
+	// numeric compares decimal codes by their numeric value
+	numeric bool
+	// strict rejects keys shorter than the output of the hash function
+	strict bool
+	// truncationOffset forces the offset of the truncation, -1 for dynamic
+	truncationOffset int
+	// extended takes the dynamic offset from the whole last byte of the digest
+	extended bool
+	// counterOrder encodes the counter into the HMAC message
+	counterOrder binary.ByteOrder
+	// modulus reduces the truncated value instead of the code space, 0 for unset
+	modulus  int
+	observer Observer
+	limiter  Limiter
+	// throttle locks HotpCounter after consecutive failures, 0 for unset
+	throttle int
+	// noResync limits HotpCounter and StoredHotp to the expected counter value
+	noResync bool
+	// encoding decodes the secrets of GenerateString and ValidateString
+	encoding SecretEncoding
 }
 
 type totp struct {
-	timeStep int
+	hotp     hotp
+	timeStep time.Duration
 	epoch    Counter
+	skew     int
+	rounding Rounding
+	clock    Clock
+	replay   ReplayStore
+}
+
+// HotpOption configures an HOTP instance. Every HotpOption configures a TOTP
+// instance as well, since TOTP generates codes the same way as HOTP.
+type HotpOption interface {
+	TotpOption
+	applyHotp(hp *hotp)
+}
+
+// TotpOption configures a TOTP instance.
+type TotpOption interface {
+	applyTotp(tp *totp)
 }
 
+type hotpOption func(*hotp)
+
+func (opt hotpOption) applyHotp(hp *hotp) { opt(hp) }
+
+func (opt hotpOption) applyTotp(tp *totp) { opt(&tp.hotp) }
+
+type totpOption func(*totp)
+
+func (opt totpOption) applyTotp(tp *totp) { opt(tp) }
+
 func defaultHotp() *hotp {
 	return &hotp{
-		hashFunc: sha1.New,
-		digits:   6,
+		hashFunc:         sha1.New,
+		digits:           6,
+		alphabet:         decimalAlphabet,
+		truncationOffset: -1,
+		counterOrder:     binary.BigEndian,
 	}
 }
 
 func defaultTotp() *totp {
 	return &totp{
-		timeStep: 30,
+		hotp:     *defaultHotp(),
+		timeStep: 30 * time.Second,
+		skew:     1,
+		clock:    realClock{},
+	}
+}
+
+// NewHotp creates a new HOTP instance for generating HMAC-Based OTP codes.
+// Out of range options are clamped to the nearest supported value.
+func NewHotp(opts ...HotpOption) *hotp {
+	hp := newHotp(opts)
+	hp.clamp()
+
+	return hp
+}
+
+// NewHotpE creates a new HOTP instance like NewHotp, but returns an error for
+// out of range options instead of clamping them.
+func NewHotpE(opts ...HotpOption) (*hotp, error) {
+	hp := newHotp(opts)
+	if err := hp.validate(); err != nil {
+		return nil, err
+	}
+
+	return hp, nil
+}
+
+// NewTotp creates a new TOTP instance for generating Time-Based OTP codes.
+// Out of range options are clamped to the nearest supported value.
+func NewTotp(opts ...TotpOption) *totp {
+	tp := newTotp(opts)
+	tp.clamp()
+
+	return tp
+}
+
+// NewTotpE creates a new TOTP instance like NewTotp, but returns an error for
+// out of range options instead of clamping them.
+func NewTotpE(opts ...TotpOption) (*totp, error) {
+	tp := newTotp(opts)
+	if err := tp.validate(); err != nil {
+		return nil, err
+	}
+
+	return tp, nil
+}
+
+// Clone returns a copy of the HOTP instance with the options applied on top
+// of its configuration. The instance itself is left unchanged. Out of range
+// options are clamped like in NewHotp.
+func (hp *hotp) Clone(opts ...HotpOption) *hotp {
+	clone := *hp
+	for _, opt := range opts {
+		opt.applyHotp(&clone)
+	}
+	clone.clamp()
+
+	return &clone
+}
+
+// Clone returns a copy of the TOTP instance with the options applied on top
+// of its configuration. The instance itself is left unchanged. The copy
+// shares the clock and the replay guard of the instance unless replaced by
+// the options. Out of range options are clamped like in NewTotp.
+func (tp *totp) Clone(opts ...TotpOption) *totp {
+	clone := *tp
+	for _, opt := range opts {
+		opt.applyTotp(&clone)
+	}
+	clone.clamp()
+
+	return &clone
+}
+
+// String describes the HOTP configuration, e.g. "HOTP(digits=6, hash=SHA1)",
+// prefixed with the label if configured, e.g. "HOTP(label=alice, digits=6,
+// hash=SHA1)". Hash functions without a HashName are described as "unknown".
+func (hp *hotp) String() string {
+	return fmt.Sprintf("HOTP(%s)", hp.describe())
+}
+
+// String describes the TOTP configuration, e.g.
+// "TOTP(digits=6, hash=SHA1, step=30s, epoch=0)".
+func (tp *totp) String() string {
+	return fmt.Sprintf("TOTP(%s, step=%v, epoch=%d)", tp.hotp.describe(), tp.timeStep, tp.epoch)
+}
+
+// Equal reports whether both HOTP instances generate the same codes. Hash
+// functions are compared by HashName, so hash functions without a name are
+// never equal. The label, the secret encoding, the observer and the attempt
+// limiter are not compared.
+func (hp *hotp) Equal(other *hotp) bool {
+	name := HashName(hp.hashFunc)
+
+	return name != "" && name == HashName(other.hashFunc) &&
+		hp.digits == other.digits &&
+		hp.alphabet == other.alphabet &&
+		hp.lsbFirst == other.lsbFirst &&
+		hp.checksum == other.checksum &&
+		hp.lenient == other.lenient &&
+		hp.numeric == other.numeric &&
+		hp.strict == other.strict &&
+		hp.truncationOffset == other.truncationOffset &&
+		hp.extended == other.extended &&
+		hp.counterOrder == other.counterOrder &&
+		hp.modulus == other.modulus
+}
+
+// Equal reports whether both TOTP instances generate and accept the same codes
+// like hotp.Equal, with the same time step, epoch, skew and rounding. The
+// clock and the replay guard are not compared.
+func (tp *totp) Equal(other *totp) bool {
+	return tp.hotp.Equal(&other.hotp) &&
+		tp.timeStep == other.timeStep &&
+		tp.epoch == other.epoch &&
+		tp.skew == other.skew &&
+		tp.rounding == other.rounding
+}
+
+func (hp *hotp) describe() string {
+	name := HashName(hp.hashFunc)
+	if name == "" {
+		name = "unknown"
+	}
+
+	if hp.label != "" {
+		return fmt.Sprintf("label=%s, digits=%d, hash=%s", hp.label, hp.digits, name)
 	}
+
+	return fmt.Sprintf("digits=%d, hash=%s", hp.digits, name)
 }
 
-// NewHotp creates a new HOTP instance for generating HMAC-Based OTP codes
-func NewHotp(opts ...func(*hotp)) *hotp {
+func newHotp(opts []HotpOption) *hotp {
 	hp := defaultHotp()
 	for _, opt := range opts {
-		opt(hp)
+		opt.applyHotp(hp)
 	}
 
 	return hp
 }
 
-// NewTotp creates a new TOTP instance for generating Time-Based OTP codes
-func NewTotp(opts ...func(*totp)) *totp {
+func newTotp(opts []TotpOption) *totp {
 	tp := defaultTotp()
 	for _, opt := range opts {
-		opt(tp)
+		opt.applyTotp(tp)
 	}
 
 	return tp
 }
 
-// WithDigits configures the number of decimal digits in the OTP code. RFC 4226
-// specifies the code length in between 6 to 9 digits. Default: 6 digits.
-func WithDigits(n int) func(*hotp) {
-	return func(hp *hotp) {
+// WithLabel configures a descriptive label of the instance, e.g. the user or
+// the device of the token, to tell instances apart in logs. The label shows
+// up in String, LabeledObserver callbacks and as the default account of the
+// provisioning URI, and never affects the codes. Default: no label.
+func WithLabel(label string) HotpOption {
+	return hotpOption(func(hp *hotp) {
+		hp.label = label
+	})
+}
+
+// WithDigits configures the number of decimal digits in the OTP code, in
+// between 6 and 10 digits. Codes of up to 9 digits follow RFC 4226, while 10
+// digit codes are rendered from a 63-bit truncated value so that all the codes
+// are equally likely. With a custom alphabet it configures the number of
+// symbols instead. Default: 6 digits.
+func WithDigits(n int) HotpOption {
+	return hotpOption(func(hp *hotp) {
 		hp.digits = n
+	})
+}
+
+// WithAlphabet configures the symbols to render OTP codes with. The truncated
+// value is rendered in the base of the alphabet length, most significant
+// symbol first, e.g. "0123456789ABCDEF" renders hexadecimal codes. The code
+// length is configured with WithDigits and is limited by the 31-bit truncated
+// value. Codes are fixed-width: short values are padded with the first symbol
+// of the alphabet, like decimal codes with leading zeros. Default:
+// "0123456789".
+func WithAlphabet(alphabet string) HotpOption {
+	return hotpOption(func(hp *hotp) {
+		hp.alphabet = alphabet
+		hp.lsbFirst = false
+	})
+}
+
+// WithChecksum configures appending the checksum digit of RFC 4226 section
+// 5.5 to the OTP code, which detects most typos in manually entered codes. The
+// code grows by one digit. Only decimal codes support the checksum digit.
+// Validation accepts codes without the checksum digit too, for clients not
+// implementing it: a code of the configured digits plus one must carry the
+// correct checksum digit, while a code of the configured digits is validated
+// as is. Default: false.
+func WithChecksum(enabled bool) HotpOption {
+	return hotpOption(func(hp *hotp) {
+		hp.checksum = enabled
+	})
+}
+
+// WithCounterEndian configures the byte order of the 8 byte counter in the HMAC
+// message. RFC 4226 mandates big-endian, but some legacy tokens were
+// programmed little-endian. A nil order restores the default. Default:
+// binary.BigEndian.
+func WithCounterEndian(order binary.ByteOrder) HotpOption {
+	return hotpOption(func(hp *hotp) {
+		if order == nil {
+			order = binary.BigEndian
+		}
+		hp.counterOrder = order
+	})
+}
+
+// WithTruncationOffset configures a fixed offset into the HMAC digest to
+// extract the code from, as supported by the reference implementation of RFC
+// 4226 appendix A. A negative value keeps the dynamic offset taken from the
+// last 4 bits of the digest. The offset must leave 4 bytes to the end of the
+// digest, e.g. at most 16 for SHA1. Default: -1 (dynamic offset).
+func WithTruncationOffset(n int) HotpOption {
+	return hotpOption(func(hp *hotp) {
+		hp.truncationOffset = n
+	})
+}
+
+// WithExtendedTruncation configures the dynamic truncation to take the offset
+// from the whole last byte of the digest, modulo the digest size minus 4,
+// instead of its last 4 bits. Offsets then range over the whole digest of
+// SHA256 and SHA512 rather than its first 19 bytes, while SHA1 codes stay the
+// same. This is not standard: the codes of longer hashes won't match RFC 6238
+// or authenticator apps. A fixed offset of WithTruncationOffset takes
+// precedence. Default: false.
+func WithExtendedTruncation(enabled bool) HotpOption {
+	return hotpOption(func(hp *hotp) {
+		hp.extended = enabled
+	})
+}
+
+// offset returns the truncation offset to pass to truncate for the digest.
+func (hp *hotp) offset(digest []byte) int {
+	if hp.extended && hp.truncationOffset < 0 && len(digest) > 4 {
+		return int(digest[len(digest)-1]) % (len(digest) - 4)
+	}
+
+	return hp.truncationOffset
+}
+
+// WithLenientInput configures validation to ignore spaces and dashes in the
+// entered code, so codes typed in groups like "123 456" or "123-456" are
+// accepted. The code must still have the configured length after the
+// separators are removed. Default: false.
+func WithLenientInput(enabled bool) HotpOption {
+	return hotpOption(func(hp *hotp) {
+		hp.lenient = enabled
+	})
+}
+
+// WithNumericCompare configures validation to compare decimal codes by their
+// numeric value, so codes stored as integers without the leading zeros, like
+// "12345" for "012345", are accepted. Has no effect with custom alphabets.
+// Default: false (codes are compared as strings).
+func WithNumericCompare(enabled bool) HotpOption {
+	return hotpOption(func(hp *hotp) {
+		hp.numeric = enabled
+	})
+}
+
+// WithStrictMode configures generation and validation to reject keys shorter
+// than the output of the hash function, as checked by ValidateKeyLength, e.g.
+// a 20 byte SHA1 key reused with SHA512. Generate returns an empty string and
+// validation fails for such keys, while GenerateE, GenerateInto and the
+// validation methods returning errors, like ValidateE, return ErrKeyLength.
+// Default: false (keys of any length are used).
+func WithStrictMode(enabled bool) HotpOption {
+	return hotpOption(func(hp *hotp) {
+		hp.strict = enabled
+	})
+}
+
+// WithModulus configures the modulus reducing the truncated value, e.g. 10000
+// for codes in 0..9999 rendered as 4 hex digits. The reduced value is rendered
+// with the configured alphabet and number of digits, so the modulus must not
+// exceed the number of distinct codes. A modulus of 0 restores the default.
+// Default: the number of distinct codes, e.g. 10^digits for decimal codes.
+func WithModulus(m int) HotpOption {
+	return hotpOption(func(hp *hotp) {
+		hp.modulus = m
+	})
+}
+
+// WithThrottle configures the throttling parameter of RFC 4226 section 7.3:
+// the number of consecutive failed verifications after which a HotpCounter
+// rejects every code until unlocked. Use WithAttemptLimiter for StoredHotp.
+// Default: 0 (no throttling).
+func WithThrottle(s int) HotpOption {
+	return hotpOption(func(hp *hotp) {
+		hp.throttle = s
+	})
+}
+
+// WithNoResync disables the resynchronization of HotpCounter and StoredHotp:
+// Verify accepts the code of the expected counter value only, whatever the
+// window, so the counter never advances past it. Meant for deployments that
+// rather re-enroll drifted tokens than widen the window for guessing.
+// Default: resynchronization within the window of Verify.
+func WithNoResync() HotpOption {
+	return hotpOption(func(hp *hotp) {
+		hp.noResync = true
+	})
+}
+
+// resyncWindow returns the look-ahead window of the stateful wrappers.
+func (hp *hotp) resyncWindow(window int) int {
+	if hp.noResync {
+		return 0
 	}
+
+	return window
 }
 
 // WithHash configures the hashing function to be used for generating OTP codes.
-// RFC 4226 specifies sha1 (default), sha256, and sha512 options.
-func WithHash(f func() hash.Hash) func(*hotp) {
-	return func(hp *hotp) {
+// RFC 4226 specifies sha1 (default), sha256, and sha512 options. Any other hash
+// function works as well, e.g. SHA3 for internal systems, but authenticator
+// apps don't support them.
+func WithHash(f func() hash.Hash) HotpOption {
+	return hotpOption(func(hp *hotp) {
 		hp.hashFunc = f
-	}
+	})
 }
 
 // WithEpoch configures the initial epoch (t0) to start counting time steps.
 // Default: 0 (the Unix epoch)
-func WithEpoch(epoch Counter) func(*totp) {
-	return func(tp *totp) {
+func WithEpoch(epoch Counter) TotpOption {
+	return totpOption(func(tp *totp) {
 		tp.epoch = epoch
-	}
+	})
 }
 
-// WithTimeStep configures the time step duration. Default: 30 seconds.
-func WithTimeStep(step time.Duration) func(*totp) {
-	return func(tp *totp) {
-		tp.timeStep = int(step.Seconds())
-	}
+// WithEpochTime configures the initial epoch (t0) to start counting time steps
+// as a point in time, which may be before the Unix epoch. The epoch has second
+// precision, fractions of a second are dropped.
+func WithEpochTime(t time.Time) TotpOption {
+	return WithEpoch(Counter(t.Unix()))
+}
+
+// WithTimeStep configures the time step duration. Steps are not limited to
+// whole seconds, but must be at least 1ms: NewTotp clamps shorter steps,
+// including zero and negative ones, and NewTotpE rejects them with
+// ErrTimeStep. Default: 30 seconds.
+func WithTimeStep(step time.Duration) TotpOption {
+	return totpOption(func(tp *totp) {
+		tp.timeStep = step
+	})
+}
+
+// WithPeriod configures the time step duration like WithTimeStep. Period is
+// the name of the time step in otpauth URIs and authenticator apps.
+func WithPeriod(period time.Duration) TotpOption {
+	return WithTimeStep(period)
+}
+
+// WithSkew configures the number of time steps accepted on either side of the
+// current one by ValidateAt, to tolerate clock drifts between a client and a
+// server. Every extra step widens the window in which a code is accepted, and
+// so the chance of guessing a valid code, by 2 codes per unit of skew. RFC
+// 6238 recommends at most one step backward. The skew is limited to 10 steps:
+// NewTotp clamps larger and negative skews, and NewTotpE rejects them with
+// ErrSkew. Use WithSkew(0) to accept the exact step only. Default: 1.
+func WithSkew(n int) TotpOption {
+	return totpOption(func(tp *totp) {
+		tp.skew = n
+	})
 }
 
 // Validate validates an OTP code against the secret key and the counter value.
 // This function checks if the provided code matches the expected OTP code for
-// the given parameters.
+// the given parameters. The comparison is constant-time, so timing does not
+// reveal how much of the code matched. Codes of the wrong length, and with the
+// checksum digit configured codes with a wrong checksum digit, are rejected
+// before computing the HMAC. ValidateE tells these apart from wrong codes.
 func (hp *hotp) Validate(key []byte, code string, counter Counter) bool {
-	return code == hp.Generate(key, counter)
+	code, ok := hp.input(code)
+	ok = ok && equal(code, hp.Generate(key, counter)) == 1
+	hp.observe(ok, 0)
+
+	return ok
+}
+
+// ValidateDigits validates an OTP code like Validate, but accepts the code in
+// any of the given lengths, e.g. both 6 and 8 digit codes while migrating
+// tokens to longer codes. The HMAC is computed once and truncated to each
+// length. Lengths outside of the supported range are ignored. With the
+// checksum digit configured, codes must carry it, as a code without it could
+// be mistaken for a code of another length.
+func (hp *hotp) ValidateDigits(key []byte, code string, counter Counter, lengths ...int) bool {
+	code = hp.normalize(code)
+	if hp.checksum && !validChecksum(code) {
+		hp.observe(false, 0)
+		return false
+	}
+	binary, err := hp.truncate(key, counter)
+	if err != nil {
+		hp.observe(false, 0)
+		return false
+	}
+	min, max := hp.digitsRange()
+	found := 0
+	for _, n := range lengths {
+		if n < min || n > max {
+			continue
+		}
+		found |= equal(code, hp.formatDigits(binary, n))
+	}
+	hp.observe(found == 1, 0)
+
+	return found == 1
+}
+
+// ValidateAuto validates an OTP code like ValidateDigits, but infers the code
+// length from the entered code, for fleets mixing 6 and 8 digit tokens. Codes
+// of 6 to 8 digits, the lengths of RFC 4226 tokens, are accepted, not counting
+// the checksum digit.
+func (hp *hotp) ValidateAuto(key []byte, code string, counter Counter) bool {
+	n := len(hp.normalize(code))
+	if hp.checksum {
+		n--
+	}
+	if n < minDigits || n > rfcDigits {
+		hp.observe(false, 0)
+		return false
+	}
+
+	return hp.ValidateDigits(key, code, counter, n)
+}
+
+// ValidateAny validates an OTP code against several candidate keys, e.g. the
+// old and the new key during a key rotation. Returns whether any key matched
+// and the index of the first matching key, or -1 when nothing matched. All
+// keys are checked in constant time, so timing does not reveal which matched.
+func (hp *hotp) ValidateAny(keys [][]byte, code string, counter Counter) (bool, int) {
+	code, ok := hp.input(code)
+	if !ok {
+		hp.observe(false, 0)
+		return false, -1
+	}
+	found, matched := 0, -1
+	for i, key := range keys {
+		eq := equal(code, hp.Generate(key, counter))
+		matched = subtle.ConstantTimeSelect(eq&^found, i, matched)
+		found |= eq
+	}
+	hp.observe(found == 1, 0)
+
+	return found == 1, matched
+}
+
+// Entry holds the secret key and the counter value of a single token for
+// ValidateBatch.
+type Entry struct {
+	Key     []byte
+	Counter Counter
+}
+
+// ValidateBatch validates an OTP code against many tokens, each with its own
+// key and counter value, to find the owner of the code, e.g. in account
+// recovery. Returns whether any entry matched and the index of the first
+// matching entry, or -1 when nothing matched. All entries are checked in
+// constant time, so timing does not reveal which stored key nearly matched.
+func (hp *hotp) ValidateBatch(entries []Entry, code string) (bool, int) {
+	code, ok := hp.input(code)
+	if !ok {
+		hp.observe(false, 0)
+		return false, -1
+	}
+	found, matched := 0, -1
+	for i, entry := range entries {
+		eq := equal(code, hp.Generate(entry.Key, entry.Counter))
+		matched = subtle.ConstantTimeSelect(eq&^found, i, matched)
+		found |= eq
+	}
+	hp.observe(found == 1, 0)
+
+	return found == 1, matched
+}
+
+// ValidateAlgorithms validates an OTP code like ValidateAny, but against several
+// candidate hash functions instead of the configured one, e.g. SHA1 and SHA256
+// while migrating tokens to another algorithm. Returns whether any hash
+// function matched and the index of the first matching one, or -1 when nothing
+// matched. All hash functions are checked in constant time, so timing does not
+// reveal which matched.
+func (hp *hotp) ValidateAlgorithms(key []byte, code string, counter Counter, hashes ...func() hash.Hash) (bool, int) {
+	code, ok := hp.input(code)
+	if !ok {
+		hp.observe(false, 0)
+		return false, -1
+	}
+	found, matched := 0, -1
+	for i, f := range hashes {
+		candidate := *hp
+		candidate.hashFunc = f
+		eq := equal(code, candidate.Generate(key, counter))
+		matched = subtle.ConstantTimeSelect(eq&^found, i, matched)
+		found |= eq
+	}
+	hp.observe(found == 1, 0)
+
+	return found == 1, matched
+}
+
+// ValidateE validates an OTP code like Validate, but returns an error telling
+// why the code is invalid: ErrCodeLength for a code of the wrong length,
+// ErrCodeFormat for symbols outside of the alphabet or a wrong checksum digit
+// and ErrCodeMismatch for a well-formed code that doesn't match. In strict
+// mode, returns ErrKeyLength for keys too short for the hash function.
+func (hp *hotp) ValidateE(key []byte, code string, counter Counter) (bool, error) {
+	code, err := hp.parse(code)
+	if err == nil {
+		var expected string
+		if expected, err = hp.GenerateE(key, counter); err == nil && equal(code, expected) != 1 {
+			err = ErrCodeMismatch
+		}
+	}
+	hp.observe(err == nil, 0)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// ValidateLookAheadE validates an HOTP code like ValidateLookAhead, but returns
+// an error telling why the code is invalid: ErrCodeLength or ErrCodeFormat for
+// a malformed code like ValidateE, ErrWindow for a window of more than 1000
+// and ErrOutsideWindow for a well-formed code that doesn't match any counter
+// value of the window.
+func (hp *hotp) ValidateLookAheadE(key []byte, code string, counter Counter, window int) (bool, Counter, error) {
+	if window > maxLookAhead {
+		hp.observe(false, 0)
+		return false, 0, fmt.Errorf("%w, must be at most %d, but was %d", ErrWindow, maxLookAhead, window)
+	}
+	if _, err := hp.parse(code); err != nil {
+		hp.observe(false, 0)
+		return false, 0, err
+	}
+	if err := hp.checkKey(key); err != nil {
+		hp.observe(false, 0)
+		return false, 0, err
+	}
+	ok, matched := hp.ValidateLookAhead(key, code, counter, window)
+	if !ok {
+		return false, 0, ErrOutsideWindow
+	}
+
+	return true, matched, nil
+}
+
+// parse normalizes the entered code like input and reports why a malformed
+// code can't be valid.
+func (hp *hotp) parse(code string) (string, error) {
+	code = hp.complete(hp.normalize(code))
+	if length := hp.codeLength(); len(code) != length {
+		return "", fmt.Errorf("%w, must be %d, but was %d", ErrCodeLength, length, len(code))
+	}
+	for i := 0; i < len(code); i++ {
+		if strings.IndexByte(hp.alphabet, code[i]) < 0 {
+			return "", fmt.Errorf("%w, unexpected symbol %q at %d", ErrCodeFormat, code[i], i)
+		}
+	}
+	if hp.checksum && !validChecksum(code) {
+		return "", fmt.Errorf("%w, wrong checksum digit", ErrCodeFormat)
+	}
+
+	return code, nil
+}
+
+// input normalizes the entered code and rejects codes that can't be valid
+// regardless of the key: codes of the wrong length, and with the checksum digit
+// configured codes with a wrong checksum digit.
+func (hp *hotp) input(code string) (string, bool) {
+	code = hp.complete(hp.normalize(code))
+	if len(code) != hp.codeLength() || hp.checksum && !validChecksum(code) {
+		return "", false
+	}
+
+	return code, true
+}
+
+// complete appends the checksum digit to a decimal code entered without it,
+// so it compares equal to the generated code with the checksum digit.
+func (hp *hotp) complete(code string) string {
+	if !hp.checksum || len(code) != hp.digits || strings.Trim(code, decimalAlphabet) != "" {
+		return code
+	}
+
+	return code + string(checksumDigit([]byte(code)))
+}
+
+// codeLength returns the length of the codes including the checksum digit.
+func (hp *hotp) codeLength() int {
+	if hp.checksum {
+		return hp.digits + 1
+	}
+
+	return hp.digits
+}
+
+// normalize strips the separators of a grouped code in lenient mode, and
+// brings decimal codes to the code length in numeric mode.
+func (hp *hotp) normalize(code string) string {
+	code = hp.strip(code)
+	if hp.numeric && hp.alphabet == decimalAlphabet {
+		code = hp.pad(code)
+	}
+
+	return code
+}
+
+// strip removes the spaces and dashes outside of the alphabet from the entered
+// code with lenient input configured.
+func (hp *hotp) strip(code string) string {
+	if !hp.lenient {
+		return code
+	}
+
+	return strings.Map(func(r rune) rune {
+		if (r == ' ' || r == '-') && !strings.ContainsRune(hp.alphabet, r) {
+			return -1
+		}
+
+		return r
+	}, code)
+}
+
+// pad replaces the leading zeros of a decimal code with as many as needed for
+// the code length, so codes with the same numeric value compare equal. Codes
+// with other symbols are returned as is.
+func (hp *hotp) pad(code string) string {
+	if code == "" || strings.Trim(code, decimalAlphabet) != "" {
+		return code
+	}
+	code = strings.TrimLeft(code, "0")
+	if n := hp.codeLength() - len(code); n > 0 {
+		code = strings.Repeat("0", n) + code
+	}
+
+	return code
+}
+
+// ValidateLookAhead validates an HOTP code against counter values from counter
+// to counter+window, following the resynchronization scheme of RFC 4226
+// section 7.4. Returns whether the code matched and the matched counter value.
+// The caller is expected to store matched+1 as the next counter value, so the
+// same code can't be accepted twice. A window of 0 accepts the code of the
+// counter value only, exactly like Validate, and negative windows are treated
+// as 0. The window is limited to 1000 counter values: larger windows are
+// clamped, and ValidateLookAheadE rejects them with ErrWindow.
+func (hp *hotp) ValidateLookAhead(key []byte, code string, counter Counter, window int) (bool, Counter) {
+	ok, matched, _ := hp.ValidateLookAheadContext(context.Background(), key, code, counter, window)
+
+	return ok, matched
+}
+
+// ValidateLookAheadContext validates an HOTP code like ValidateLookAhead, but
+// stops scanning the window when the context is done and returns its error.
+func (hp *hotp) ValidateLookAheadContext(ctx context.Context, key []byte, code string, counter Counter, window int) (bool, Counter, error) {
+	ok, matched, err := hp.lookAhead(ctx, key, code, counter, window)
+	if err != nil {
+		return false, 0, err
+	}
+	hp.observe(ok, lookAheadOffset(ok, matched, counter))
+
+	return ok, matched, nil
+}
+
+// lookAhead scans the look-ahead window like ValidateLookAheadContext without
+// notifying the observer, for callers that combine the result with other
+// checks before reporting it.
+func (hp *hotp) lookAhead(ctx context.Context, key []byte, code string, counter Counter, window int) (bool, Counter, error) {
+	code, ok := hp.input(code)
+	if !ok {
+		return false, 0, nil
+	}
+	if window < 0 {
+		window = 0
+	}
+	if window > maxLookAhead {
+		window = maxLookAhead
+	}
+	g := hp.generator(key)
+	defer g.release()
+	found, matched := 0, Counter(0)
+	for i := 0; i <= window; i++ {
+		if err := ctx.Err(); err != nil {
+			return false, 0, err
+		}
+		expected := g.generate(counter + Counter(i))
+		eq := equal(code, expected)
+		mask := -Counter(eq &^ found)
+		matched = matched&^mask | (counter+Counter(i))&mask
+		found |= eq
+	}
+
+	return found == 1, matched, nil
+}
+
+// lookAheadOffset returns the look-ahead distance reported to the observer, 0
+// when nothing matched.
+func lookAheadOffset(ok bool, matched, counter Counter) int {
+	if !ok {
+		return 0
+	}
+
+	return int(matched - counter)
 }
 
 // Generate generates an OTP code using the given secret key and the counter
-// value. Returns the code as a string.
+// value. Returns the code as a string, or an empty string if the configured
+// hash function produces digests too short for the truncation, or the key is
+// rejected in strict mode.
 func (hp *hotp) Generate(key []byte, counter Counter) string {
-	mac := hmac.New(hp.hashFunc, key)
-	mac.Write(toBinary(uint64(counter)))
-	code := truncate(mac.Sum(nil), hp.digits)
+	g := hp.generator(key)
+	defer g.release()
+
+	return g.generate(counter)
+}
+
+// GenerateE generates an OTP code like Generate, but returns ErrDigestSize if
+// the configured hash function produces digests too short for the truncation,
+// and ErrKeyLength for keys rejected in strict mode.
+func (hp *hotp) GenerateE(key []byte, counter Counter) (string, error) {
+	binary, err := hp.truncate(key, counter)
+	if err != nil {
+		return "", err
+	}
+
+	return hp.format(binary), nil
+}
+
+// GenerateInto generates an OTP code like Generate, but writes it into dst
+// instead of allocating a string, for high-volume generation. Returns the
+// length of the code, or io.ErrShortBuffer if dst can't hold the code. Like
+// GenerateE, returns ErrDigestSize for digests too short for the truncation.
+func (hp *hotp) GenerateInto(dst []byte, key []byte, counter Counter) (int, error) {
+	n := hp.codeLength()
+	if len(dst) < n {
+		return 0, io.ErrShortBuffer
+	}
+	binary, err := hp.truncate(key, counter)
+	if err != nil {
+		return 0, err
+	}
+	hp.appendCode(dst[:0], binary, hp.digits)
+
+	return n, nil
+}
 
-	return fmt.Sprintf("%0*d", hp.digits, code)
+// GenerateAppend generates an OTP code like Generate and appends it to dst,
+// like the strconv Append functions, so hot paths can reuse one buffer instead
+// of allocating a string per code. Like GenerateE, returns ErrDigestSize for
+// digests too short for the truncation, with dst unchanged.
+func (hp *hotp) GenerateAppend(dst []byte, key []byte, counter Counter) ([]byte, error) {
+	binary, err := hp.truncate(key, counter)
+	if err != nil {
+		return dst, err
+	}
+
+	return hp.appendCode(dst, binary, hp.digits), nil
+}
+
+// WriteCode generates an OTP code like Generate and writes it to w, e.g. to
+// stream many codes into a file or an HTTP response. Returns the number of
+// bytes written.
+func (hp *hotp) WriteCode(w io.Writer, key []byte, counter Counter) (int, error) {
+	var buf [32]byte
+	n, err := hp.GenerateInto(buf[:], key, counter)
+	if err != nil {
+		return 0, err
+	}
+
+	return w.Write(buf[:n])
+}
+
+// GenerateRange generates n consecutive OTP codes for the counter values from
+// start to start+n-1, e.g. to print a sheet of offline codes.
+func (hp *hotp) GenerateRange(key []byte, start Counter, n int) []string {
+	if n <= 0 {
+		return nil
+	}
+	g := hp.generator(key)
+	defer g.release()
+	codes := make([]string, n)
+	for i := range codes {
+		codes[i] = g.generate(start + Counter(i))
+	}
+
+	return codes
+}
+
+// generator generates codes for a single key. The keyed HMAC and the digest
+// buffer are reused across counter values, which saves allocations when
+// scanning a validation window.
+type generator struct {
+	hp  *hotp
+	mac hash.Hash
+	// err rejects the key in strict mode
+	err    error
+	used   bool
+	msg    [8]byte
+	digest [64]byte
+	// pool recycles the generator on release, nil if not pooled
+	pool *sync.Pool
+}
+
+// generator returns a generator of the key, recycled from the pool of the hash
+// function if there is one. The generator must be released after use.
+func (hp *hotp) generator(key []byte) *generator {
+	p := generatorPools[reflect.ValueOf(hp.hashFunc).Pointer()]
+	if p == nil {
+		return hp.keyedGenerator(key)
+	}
+	g := p.Get().(*generator)
+	g.hp = hp
+	g.rekey(key)
+
+	return g
+}
+
+// keyedGenerator returns a generator of the key backed by crypto/hmac, which
+// resets to the keyed state faster than a pooled generator for long-lived use.
+func (hp *hotp) keyedGenerator(key []byte) *generator {
+	return &generator{hp: hp, mac: hmac.New(hp.hashFunc, key), err: hp.checkKey(key)}
+}
+
+// truncate generates the truncated value of a single code.
+func (hp *hotp) truncate(key []byte, counter Counter) (int64, error) {
+	g := hp.generator(key)
+	defer g.release()
+
+	return g.truncate(counter)
+}
+
+// checkKey rejects keys shorter than the output of the hash function in strict
+// mode.
+func (hp *hotp) checkKey(key []byte) error {
+	if !hp.strict {
+		return nil
+	}
+
+	return ValidateKeyLength(key, hp.hashFunc)
+}
+
+// generate generates the code of the counter, or an empty string that matches
+// no code if the key is rejected or the digest is too short for the truncation.
+func (g *generator) generate(counter Counter) string {
+	binary, err := g.truncate(counter)
+	if err != nil {
+		return ""
+	}
+
+	return g.hp.format(binary)
+}
+
+// truncate computes the HMAC of the counter and returns its truncated value.
+func (g *generator) truncate(counter Counter) (int64, error) {
+	if g.err != nil {
+		return 0, g.err
+	}
+
+	digest := g.sum(counter)
+
+	return truncate(digest, g.hp.offset(digest))
+}
+
+// sum computes the HMAC of the counter into the digest buffer of the
+// generator, valid until the next call.
+func (g *generator) sum(counter Counter) []byte {
+	// A fresh HMAC needs no reset, which saves saving its keyed state for
+	// single codes.
+	if g.used {
+		g.mac.Reset()
+	}
+	g.used = true
+	g.hp.counterOrder.PutUint64(g.msg[:], uint64(counter))
+	g.mac.Write(g.msg[:])
+
+	return g.mac.Sum(g.digest[:0])
+}
+
+// Digest returns the HMAC of the counter value with the configured hash
+// function, the HS value of RFC 4226 before the dynamic truncation, e.g. for
+// custom truncations or debugging against the intermediate values of the RFC
+// 4226 test vectors. Generate truncates the same digest.
+func (hp *hotp) Digest(key []byte, counter Counter) []byte {
+	g := hp.generator(key)
+	defer g.release()
+
+	return append([]byte(nil), g.sum(counter)...)
+}
+
+// Explain returns the intermediate values of generating the code for
+// debugging, e.g. to compare against the test tools of token vendors: the
+// offset into the HMAC digest, the 31-bit value extracted at the offset as in
+// RFC 4226 and the resulting code. 10 digit codes are rendered from the 63-bit
+// value extending the 31-bit one. Returns an offset of -1 and no code if the
+// code can't be generated, like GenerateE.
+func (hp *hotp) Explain(key []byte, counter Counter) (offset int, binary int, code string) {
+	g := hp.generator(key)
+	defer g.release()
+	if g.err != nil {
+		return -1, 0, ""
+	}
+	digest := g.sum(counter)
+	offset = hp.offset(digest)
+	value, err := truncate(digest, offset)
+	if err != nil {
+		return -1, 0, ""
+	}
+
+	return truncationOffset(digest, offset), int(value >> 32), hp.format(value)
+}
+
+// GenerateInt generates an OTP code like Generate, but returns the numeric
+// value of the code. For decimal codes it equals the parsed Generate result,
+// including the checksum digit when configured. For custom alphabets it is the
+// value the code represents in the base of the alphabet. Returns -1 if the
+// digest is too short for the truncation.
+func (hp *hotp) GenerateInt(key []byte, counter Counter) int64 {
+	binary, err := hp.truncate(key, counter)
+	if err != nil {
+		return -1
+	}
+	value := hp.value(binary, hp.digits) % hp.CodeSpace()
+	if hp.checksum {
+		code := hp.format(binary)
+		value = value*10 + int64(code[len(code)-1]-'0')
+	}
+
+	return value
+}
+
+// CodeSpace returns the number of distinct codes: the alphabet size to the
+// power of the number of digits, e.g. 10^6 for the default 6 digit codes, or
+// the modulus when configured. The checksum digit adds no distinct codes. A
+// single guess matches with the probability 1/CodeSpace() per accepted counter
+// value, which helps tuning validation windows and lockouts.
+func (hp *hotp) CodeSpace() int64 {
+	if hp.modulus > 0 {
+		return int64(hp.modulus)
+	}
+
+	return hp.digitsSpace()
+}
+
+// digitsSpace returns the number of distinct codes of the configured length,
+// not counting the checksum digit.
+func (hp *hotp) digitsSpace() int64 {
+	space := int64(1)
+	for i := 0; i < hp.digits; i++ {
+		space *= int64(len(hp.alphabet))
+	}
+
+	return space
+}
+
+// format renders the truncated value as a code of the configured length.
+func (hp *hotp) format(binary int64) string {
+	return hp.formatDigits(binary, hp.digits)
+}
+
+// formatDigits renders the truncated value as a code of the given length.
+func (hp *hotp) formatDigits(binary int64, digits int) string {
+	return string(hp.appendCode(make([]byte, 0, digits+1), binary, digits))
+}
+
+// appendCode renders the truncated value as a code of the given length and
+// appends it to dst.
+func (hp *hotp) appendCode(dst []byte, binary int64, digits int) []byte {
+	start := len(dst)
+	for i := 0; i < digits; i++ {
+		dst = append(dst, 0)
+	}
+	code := dst[start:]
+	value := hp.value(binary, digits)
+	if hp.modulus > 0 {
+		value %= int64(hp.modulus)
+	}
+	base := int64(len(hp.alphabet))
+	for i := range code {
+		pos := len(code) - 1 - i
+		if hp.lsbFirst {
+			pos = i
+		}
+		code[pos] = hp.alphabet[value%base]
+		value /= base
+	}
+	if hp.checksum {
+		dst = append(dst, checksumDigit(code))
+	}
+
+	return dst
+}
+
+// value selects the bits of the 63-bit truncated value to render a code of
+// the given length from: the 31-bit value of RFC 4226, or the whole value for
+// 10 digit decimal codes, which the 31-bit value can't cover evenly.
+func (hp *hotp) value(binary int64, digits int) int64 {
+	if hp.alphabet == decimalAlphabet && digits > narrowDigits {
+		return binary
+	}
+
+	return binary >> 32
+}
+
+// checksumDigit calculates the checksum digit of the decimal code with the
+// Luhn algorithm, as in the reference implementation of RFC 4226.
+func checksumDigit(code []byte) byte {
+	total := 0
+	for i := 0; i < len(code); i++ {
+		digit := int(code[len(code)-1-i] - '0')
+		if i%2 == 0 {
+			digit *= 2
+			if digit > 9 {
+				digit -= 9
+			}
+		}
+		total += digit
+	}
+
+	return byte('0' + (10-total%10)%10)
+}
+
+func validChecksum(code string) bool {
+	if code == "" {
+		return false
+	}
+	for i := 0; i < len(code); i++ {
+		if code[i] < '0' || code[i] > '9' {
+			return false
+		}
+	}
+
+	return checksumDigit([]byte(code[:len(code)-1])) == code[len(code)-1]
+}
+
+// FormatCode groups the code for display by inserting the separator every
+// groupSize characters, e.g. FormatCode("123456", 3, " ") returns "123 456".
+// The last group is shorter when the code length isn't a multiple of the group
+// size. A non-positive group size returns the code as is.
+func FormatCode(code string, groupSize int, sep string) string {
+	if groupSize <= 0 || len(code) <= groupSize {
+		return code
+	}
+	var b strings.Builder
+	for i := 0; i < len(code); i += groupSize {
+		if i > 0 {
+			b.WriteString(sep)
+		}
+		end := i + groupSize
+		if end > len(code) {
+			end = len(code)
+		}
+		b.WriteString(code[i:end])
+	}
+
+	return b.String()
+}
+
+// CodeSpace returns the number of distinct codes like hotp.CodeSpace. Each
+// time step within the skew adds one accepted code.
+func (tp *totp) CodeSpace() int64 {
+	return tp.hotp.CodeSpace()
+}
+
+// TimeStep returns the configured time step.
+func (tp *totp) TimeStep() time.Duration {
+	return tp.timeStep
+}
+
+// Epoch returns the configured initial epoch in Unix seconds.
+func (tp *totp) Epoch() Counter {
+	return tp.epoch
 }
 
 // At calculates the counter value for TOTP code generation. TOTP uses the
-// counter that represents time periods since the initial epoch.
+// counter that represents time periods since the initial epoch, rounded down
+// unless configured otherwise by WithRounding. Times before the epoch are
+// clamped to the counter 0.
 func (tp *totp) At(t time.Time) Counter {
-	return Counter((uint64(t.Unix()) - uint64(tp.epoch)) / uint64(tp.timeStep))
+	counter, _ := tp.step(t)
+
+	return counter
+}
+
+// RemainingTime returns the time left until the next counter value, when the
+// code for the time t expires.
+func (tp *totp) RemainingTime(t time.Time) time.Duration {
+	if tp.beforeEpoch(t) {
+		return tp.start(1).Sub(t)
+	}
+	_, elapsed := tp.step(t)
+
+	return tp.timeStep - elapsed
+}
+
+// ExpiresAt returns the time when the code for the time t expires, the start
+// of the next time step. At(ExpiresAt(t)) is always At(t)+1.
+func (tp *totp) ExpiresAt(t time.Time) time.Time {
+	return t.Add(tp.RemainingTime(t))
+}
+
+// NearExpiry reports whether the code for the time t expires in less than the
+// threshold, e.g. to warn users to wait for the next code rather than submit
+// one that could expire in flight. It doesn't need the key.
+func (tp *totp) NearExpiry(t time.Time, threshold time.Duration) bool {
+	return tp.RemainingTime(t) < threshold
 }
 
-func toBinary(val uint64) []byte {
-	buf := make([]byte, 8)
-	binary.BigEndian.PutUint64(buf, val)
+// TimeOfCounter returns the start time of the time step of the counter value,
+// from which on its code is current, the inverse of At: At(TimeOfCounter(c))
+// is always c.
+func (tp *totp) TimeOfCounter(c Counter) time.Time {
+	return tp.start(c)
+}
+
+// Progress returns the elapsed fraction of the time step containing t, in the
+// range [0, 1). Times before the epoch have no progress.
+func (tp *totp) Progress(t time.Time) float64 {
+	_, elapsed := tp.step(t)
+
+	return float64(elapsed) / float64(tp.timeStep)
+}
+
+// step returns the counter of the time step containing t and the time elapsed
+// since the step started.
+func (tp *totp) step(t time.Time) (Counter, time.Duration) {
+	if tp.beforeEpoch(t) {
+		return 0, 0
+	}
+	t = t.Add(tp.shift())
+	secs := uint64(t.Unix()) - uint64(tp.epoch)
+	hi, lo := bits.Mul64(secs, uint64(time.Second))
+	lo, carry := bits.Add64(lo, uint64(t.Nanosecond()), 0)
+	step := uint64(tp.timeStep)
+	counter, elapsed := bits.Div64((hi+carry)%step, lo, step)
+
+	return Counter(counter), time.Duration(elapsed)
+}
+
+// Code generates a TOTP code using the given secret key for the time step
+// containing t. Returns the code as a string.
+func (tp *totp) Code(key []byte, t time.Time) string {
+	return tp.hotp.Generate(key, tp.At(t))
+}
+
+// Expected returns the code expected by ValidateAt at time t, the same as
+// Code, for support and verification tooling, e.g. to help a user who can't
+// read the code of their app.
+//
+// Security: the expected code grants access just like the secret key until
+// the time step ends. Never log it, never show it to anyone but an authorized
+// operator, and never expose it through an endpoint reachable by users. Prefer
+// ValidateAt whenever the code to check is at hand.
+func (tp *totp) Expected(key []byte, t time.Time) string {
+	return tp.Code(key, t)
+}
+
+// ValidateAt validates a TOTP code against the secret key at the given time.
+// The code is accepted if it matches any time step within the configured skew
+// around At(t). All steps of the window are checked with a constant-time
+// comparison, so timing does not reveal which step matched.
+func (tp *totp) ValidateAt(key []byte, code string, t time.Time) bool {
+	ok, _ := tp.ValidateOffset(key, code, t)
+
+	return ok
+}
+
+// ValidateInRange validates a TOTP code against every time step covering the
+// range from..to, e.g. to verify historical codes in logs. Returns whether the
+// code matched and the start time of the first matching time step. Returns
+// ErrRangeTooLarge if the range covers more than 1000000 time steps. Not meant
+// for live authentication, use ValidateAt with a skew instead.
+func (tp *totp) ValidateInRange(key []byte, code string, from, to time.Time) (bool, time.Time, error) {
+	code, ok := tp.hotp.input(code)
+	if !ok || to.Before(from) || tp.beforeEpoch(to) {
+		return false, time.Time{}, nil
+	}
+	first, last := tp.At(from), tp.At(to)
+	if last-first >= maxRangeSteps {
+		return false, time.Time{}, fmt.Errorf("%w, must be at most %d steps, but was %d", ErrRangeTooLarge, maxRangeSteps, last-first+1)
+	}
+	g := tp.hotp.generator(key)
+	defer g.release()
+	for counter := first; counter <= last; counter++ {
+		if equal(code, g.generate(counter)) == 1 {
+			return true, tp.start(counter), nil
+		}
+	}
+
+	return false, time.Time{}, nil
+}
+
+// ValidateAtE validates a TOTP code like ValidateAt, but returns an error
+// telling why the code is invalid: ErrCodeLength or ErrCodeFormat for a
+// malformed code like hotp.ValidateE and ErrOutsideWindow for a well-formed
+// code that doesn't match any time step within the skew, including at times
+// before the epoch.
+func (tp *totp) ValidateAtE(key []byte, code string, t time.Time) (bool, error) {
+	if _, err := tp.hotp.parse(code); err != nil {
+		tp.hotp.observe(false, 0)
+		return false, err
+	}
+	if err := tp.hotp.checkKey(key); err != nil {
+		tp.hotp.observe(false, 0)
+		return false, err
+	}
+	if !tp.ValidateAt(key, code, t) {
+		return false, ErrOutsideWindow
+	}
+
+	return true, nil
+}
+
+// ValidateOffset validates a TOTP code like ValidateAt and also returns the
+// signed offset of the matched time step relative to At(t), e.g. -1 when the
+// code was generated one step behind. The offset is 0 when nothing matched.
+// Offsets collected over time reveal systematic clock drifts of a client.
+// No code is valid at times before the epoch.
+func (tp *totp) ValidateOffset(key []byte, code string, t time.Time) (bool, int) {
+	ok, offset, _ := tp.ValidateOffsetContext(context.Background(), key, code, t)
+
+	return ok, offset
+}
+
+// ValidateOffsetContext validates a TOTP code like ValidateOffset, but stops
+// scanning the skew window when the context is done and returns its error.
+func (tp *totp) ValidateOffsetContext(ctx context.Context, key []byte, code string, t time.Time) (bool, int, error) {
+	ok, offset, err := tp.scan(ctx, key, code, t)
+	if err != nil {
+		return false, 0, err
+	}
+	tp.hotp.observe(ok, offset)
+
+	return ok, offset, nil
+}
+
+// scan scans the skew window like ValidateOffsetContext without notifying the
+// observer, for callers that combine the result with other checks before
+// reporting it.
+func (tp *totp) scan(ctx context.Context, key []byte, code string, t time.Time) (bool, int, error) {
+	code, ok := tp.hotp.input(code)
+	if !ok || tp.beforeEpoch(t) {
+		return false, 0, nil
+	}
+	counter := tp.At(t)
+	g := tp.hotp.generator(key)
+	defer g.release()
+	found, matched := 0, 0
+	for offset := -tp.skew; offset <= tp.skew; offset++ {
+		if err := ctx.Err(); err != nil {
+			return false, 0, err
+		}
+		if offset < 0 && Counter(-offset) > counter {
+			continue
+		}
+		expected := g.generate(counter + Counter(offset))
+		eq := equal(code, expected)
+		matched = subtle.ConstantTimeSelect(eq&^found, offset, matched)
+		found |= eq
+	}
+
+	return found == 1, matched, nil
+}
+
+// ValidCodes returns the codes accepted at the given time with the given skew,
+// from the oldest to the newest time step, or nil before the epoch. A negative
+// skew is treated as 0. This is a helper for tests and debugging: the codes
+// are as sensitive as the key, so they must not be logged or returned to
+// clients in production.
+func (tp *totp) ValidCodes(key []byte, t time.Time, skew int) []string {
+	if tp.beforeEpoch(t) {
+		return nil
+	}
+	if skew < 0 {
+		skew = 0
+	}
+	counter := tp.At(t)
+	g := tp.hotp.generator(key)
+	defer g.release()
+	codes := make([]string, 0, 2*skew+1)
+	for offset := -skew; offset <= skew; offset++ {
+		if offset < 0 && Counter(-offset) > counter {
+			continue
+		}
+		codes = append(codes, g.generate(counter+Counter(offset)))
+	}
+
+	return codes
+}
+
+// CodeHistory returns the codes of the last n time steps up to the one
+// containing t, for the counter values At(t)-n+1 to At(t), oldest first. Fewer
+// codes are returned near the epoch and none before it. Like ValidCodes, this
+// is meant for support views and the codes must be kept as secret as the key.
+func (tp *totp) CodeHistory(key []byte, t time.Time, n int) []string {
+	if n <= 0 || tp.beforeEpoch(t) {
+		return nil
+	}
+	counter := tp.At(t)
+	if Counter(n) > counter+1 {
+		n = int(counter + 1)
+	}
+
+	return tp.hotp.GenerateRange(key, counter-Counter(n-1), n)
+}
+
+func (hp *hotp) clamp() {
+	if !validAlphabet(hp.alphabet) {
+		hp.alphabet = decimalAlphabet
+		hp.lsbFirst = false
+	}
+	if hp.alphabet != decimalAlphabet {
+		hp.checksum = false
+	}
+	if hp.truncationOffset > hp.hashFunc().Size()-4 {
+		hp.truncationOffset = -1
+	}
+	min, max := hp.digitsRange()
+	if hp.digits < min {
+		hp.digits = min
+	}
+	if hp.digits > max {
+		hp.digits = max
+	}
+	if hp.modulus < 2 || int64(hp.modulus) > hp.digitsSpace() {
+		hp.modulus = 0
+	}
+	if hp.encoding < EncodingBase32 || hp.encoding > EncodingRaw {
+		hp.encoding = EncodingBase32
+	}
+}
+
+func (hp *hotp) validate() error {
+	if !validAlphabet(hp.alphabet) {
+		return fmt.Errorf("%w, but was %q", ErrAlphabet, hp.alphabet)
+	}
+	if hp.checksum && hp.alphabet != decimalAlphabet {
+		return ErrChecksum
+	}
+	size := hp.hashFunc().Size()
+	if max := size - 4; hp.truncationOffset > max {
+		return fmt.Errorf("%w, must be at most %d, but was %d", ErrTruncationOffset, max, hp.truncationOffset)
+	}
+	if hp.truncationOffset < 0 && size < 20 {
+		return fmt.Errorf("%w, must be at least 20 bytes, but was %d", ErrDigestSize, size)
+	}
+	min, max := hp.digitsRange()
+	if hp.digits < min || hp.digits > max {
+		return fmt.Errorf("%w, must be in between %d and %d, but was %d", ErrDigits, min, max, hp.digits)
+	}
+	if hp.modulus != 0 && (hp.modulus < 2 || int64(hp.modulus) > hp.digitsSpace()) {
+		return fmt.Errorf("%w, must be in between 2 and %d, but was %d", ErrModulus, hp.digitsSpace(), hp.modulus)
+	}
+	if hp.encoding < EncodingBase32 || hp.encoding > EncodingRaw {
+		return fmt.Errorf("%w, but was %v", ErrSecretEncoding, hp.encoding)
+	}
+
+	return nil
+}
+
+// digitsRange returns the supported range of the code length. Decimal codes
+// range from 6 to 10 digits, custom alphabets are limited by the 31-bit value.
+func (hp *hotp) digitsRange() (int, int) {
+	if hp.alphabet == decimalAlphabet {
+		return minDigits, maxDigits
+	}
+	max := 0
+	for space := int64(len(hp.alphabet)); space <= 1<<31; space *= int64(len(hp.alphabet)) {
+		max++
+	}
+
+	return 1, max
+}
+
+func validAlphabet(alphabet string) bool {
+	if len(alphabet) < 2 {
+		return false
+	}
+	var seen [128]bool
+	for i := 0; i < len(alphabet); i++ {
+		c := alphabet[i]
+		if c >= 128 || seen[c] {
+			return false
+		}
+		seen[c] = true
+	}
 
-	return buf
+	return true
 }
 
-func truncate(digest []byte, digits int) int {
-	offset := digest[len(digest)-1] & 0xf
-	binary := int(digest[offset]&0x7f)<<24 |
-		int(digest[offset+1]&0xff)<<16 |
-		int(digest[offset+2]&0xff)<<8 |
-		int(digest[offset+3]&0xff)
+func (tp *totp) clamp() {
+	tp.hotp.clamp()
+	if tp.timeStep < minTimeStep {
+		tp.timeStep = minTimeStep
+	}
+	if tp.skew < 0 {
+		tp.skew = 0
+	}
+	if tp.skew > maxSkew {
+		tp.skew = maxSkew
+	}
+	if tp.rounding < RoundFloor || tp.rounding > RoundCeil {
+		tp.rounding = RoundFloor
+	}
+}
+
+func (tp *totp) validate() error {
+	if tp.timeStep < minTimeStep {
+		return fmt.Errorf("%w, but was %v", ErrTimeStep, tp.timeStep)
+	}
+	if tp.skew < 0 || tp.skew > maxSkew {
+		return fmt.Errorf("%w, must be in between 0 and %d, but was %d", ErrSkew, maxSkew, tp.skew)
+	}
+	if tp.rounding < RoundFloor || tp.rounding > RoundCeil {
+		return fmt.Errorf("%w, but was %v", ErrRounding, tp.rounding)
+	}
+
+	return tp.hotp.validate()
+}
+
+// start returns the start time of the time step of the counter.
+func (tp *totp) start(counter Counter) time.Time {
+	return time.Unix(int64(tp.epoch), 0).Add(time.Duration(counter)*tp.timeStep - tp.shift())
+}
+
+func (tp *totp) beforeEpoch(t time.Time) bool {
+	return t.Add(tp.shift()).Unix() < int64(tp.epoch)
+}
+
+// equal compares two codes in constant time and returns 1 if they are equal.
+func equal(code, expected string) int {
+	return subtle.ConstantTimeCompare([]byte(code), []byte(expected))
+}
+
+// truncate extracts the 31-bit value from the digest at the given offset, or
+// at the dynamic offset when the given one is out of range, and widens it to
+// 63 bits with the 4 bytes that follow, wrapping around the end of the digest.
+// Returns ErrDigestSize if the digest ends before the 4 bytes at the offset.
+func truncate(digest []byte, offset int) (int64, error) {
+	if len(digest) == 0 {
+		return 0, ErrDigestSize
+	}
+	offset = truncationOffset(digest, offset)
+	if offset+4 > len(digest) {
+		return 0, fmt.Errorf("%w, needs %d bytes, but was %d", ErrDigestSize, offset+4, len(digest))
+	}
+
+	value := int64(digest[offset]&0x7f)<<24 |
+		int64(digest[offset+1]&0xff)<<16 |
+		int64(digest[offset+2]&0xff)<<8 |
+		int64(digest[offset+3]&0xff)
+	for i := offset + 4; i < offset+8; i++ {
+		value = value<<8 | int64(digest[i%len(digest)])
+	}
+
+	return value, nil
+}
+
+// truncationOffset returns the given offset, or the dynamic offset taken from
+// the last 4 bits of the non-empty digest when the given one is out of range.
+func truncationOffset(digest []byte, offset int) int {
+	if offset < 0 || offset > len(digest)-4 {
+		return int(digest[len(digest)-1] & 0xf)
+	}
 
-	return binary % int(math.Pow10(digits))
+	return offset
 }
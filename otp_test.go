@@ -1,10 +1,18 @@
 package otp
 
 import (
+	"context"
 	"crypto/sha1"
 	"crypto/sha256"
 	"crypto/sha512"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
 	"hash"
+	"io"
+	"math"
+	"strings"
 	"testing"
 	"time"
 )
@@ -38,6 +46,26 @@ func TestEpochs(t *testing.T) {
 	}
 }
 
+func TestBeforeEpoch(t *testing.T) {
+	key := []byte("12345678901234567890")
+	totp := NewTotp(WithEpoch(100), WithTimeStep(10*time.Second), WithSkew(1))
+	for _, unix := range []int64{99, 50, 0, -100} {
+		if c := totp.At(time.Unix(unix, 0)); c != 0 {
+			t.Logf("Expected %d, but was %d", 0, c)
+			t.Fail()
+		}
+		code := totp.Code(key, time.Unix(100, 0))
+		if totp.ValidateAt(key, code, time.Unix(unix, 0)) {
+			t.Logf("Code %s expected to be invalid before the epoch", code)
+			t.Fail()
+		}
+	}
+	if c := NewTotp().At(time.Unix(-1, 0)); c != 0 {
+		t.Logf("Expected %d, but was %d", 0, c)
+		t.Fail()
+	}
+}
+
 func TestHOTPVectors(t *testing.T) {
 	key20 := []byte("12345678901234567890")
 	testCases := []struct {
@@ -233,6 +261,1642 @@ func TestTOTPVectors(t *testing.T) {
 				t.Logf("Code %s expected to be valid", code)
 				t.Fail()
 			}
+			totp = NewTotp(WithHash(tC.hashFunc), WithDigits(8))
+			if code := totp.Code(tC.key, tC.unixTime); code != tC.code {
+				t.Logf("Expected code %s, but was %s", tC.code, code)
+				t.Fail()
+			}
+			if !totp.ValidateAt(tC.key, tC.code, tC.unixTime) {
+				t.Logf("Code %s expected to be valid", tC.code)
+				t.Fail()
+			}
+		})
+	}
+}
+
+func TestSkewRange(t *testing.T) {
+	testCases := []struct {
+		skew    int
+		clamped int
+	}{
+		{skew: -1, clamped: 0},
+		{skew: 11, clamped: maxSkew},
+		{skew: 1000, clamped: maxSkew},
+	}
+	for _, tC := range testCases {
+		t.Run("Skew out of range", func(t *testing.T) {
+			if skew := NewTotp(WithSkew(tC.skew)).skew; skew != tC.clamped {
+				t.Logf("Expected skew %d, but was %d", tC.clamped, skew)
+				t.Fail()
+			}
+			if _, err := NewTotpE(WithSkew(tC.skew)); !errors.Is(err, ErrSkew) {
+				t.Logf("Expected %v, but was %v", ErrSkew, err)
+				t.Fail()
+			}
+		})
+	}
+	if _, err := NewTotpE(WithSkew(maxSkew)); err != nil {
+		t.Logf("Expected no error, but was %v", err)
+		t.Fail()
+	}
+}
+
+func TestDefaultSkew(t *testing.T) {
+	key20 := []byte("12345678901234567890")
+	totp := NewTotp()
+	at := time.Unix(59, 0)
+	for _, code := range []string{"755224", "287082", "359152"} {
+		if !totp.ValidateAt(key20, code, at) {
+			t.Logf("Code %s expected to be valid within one time step", code)
+			t.Fail()
+		}
+	}
+	if totp.ValidateAt(key20, "969429", at) {
+		t.Logf("Code %s expected to be invalid two time steps ahead", "969429")
+		t.Fail()
+	}
+	if NewTotp(WithSkew(0)).ValidateAt(key20, "755224", at) {
+		t.Logf("Code %s expected to be invalid with no skew", "755224")
+		t.Fail()
+	}
+}
+
+func TestSkew(t *testing.T) {
+	key := []byte("12345678901234567890")
+	hotp := NewHotp()
+	now := time.Unix(1111111109, 0)
+	testCases := []struct {
+		skew   int
+		offset int
+		valid  bool
+	}{
+		{skew: 0, offset: 0, valid: true},
+		{skew: 0, offset: -1, valid: false},
+		{skew: 0, offset: 1, valid: false},
+		{skew: 1, offset: -1, valid: true},
+		{skew: 1, offset: 1, valid: true},
+		{skew: 1, offset: -2, valid: false},
+		{skew: 1, offset: 2, valid: false},
+		{skew: 2, offset: -2, valid: true},
+		{skew: 2, offset: 2, valid: true},
+	}
+	for _, tC := range testCases {
+		t.Run("TOTP validation window", func(t *testing.T) {
+			totp := NewTotp(WithSkew(tC.skew))
+			code := hotp.Generate(key, totp.At(now)+Counter(tC.offset))
+			if valid := totp.ValidateAt(key, code, now); valid != tC.valid {
+				t.Logf("Expected %t for skew %d and offset %d, but was %t", tC.valid, tC.skew, tC.offset, valid)
+				t.Fail()
+			}
+		})
+	}
+}
+
+func TestSkewAtFirstStep(t *testing.T) {
+	key := []byte("12345678901234567890")
+	hotp := NewHotp()
+	totp := NewTotp(WithSkew(1))
+	code := hotp.Generate(key, 0)
+	if !totp.ValidateAt(key, code, time.Unix(0, 0)) {
+		t.Logf("Code %s expected to be valid", code)
+		t.Fail()
+	}
+}
+
+func TestSkewOffset(t *testing.T) {
+	key := []byte("12345678901234567890")
+	hotp := NewHotp()
+	totp := NewTotp(WithSkew(2))
+	now := time.Unix(1111111109, 0)
+	for _, offset := range []int{-2, -1, 0, 1, 2} {
+		code := hotp.Generate(key, totp.At(now)+Counter(offset))
+		valid, matched := totp.ValidateOffset(key, code, now)
+		if !valid || matched != offset {
+			t.Logf("Expected offset %d, but was %d (valid: %t)", offset, matched, valid)
+			t.Fail()
+		}
+	}
+	code := hotp.Generate(key, totp.At(now)+3)
+	if valid, matched := totp.ValidateOffset(key, code, now); valid || matched != 0 {
+		t.Logf("Expected no match, but was offset %d (valid: %t)", matched, valid)
+		t.Fail()
+	}
+}
+
+func TestLookAhead(t *testing.T) {
+	key := []byte("12345678901234567890")
+	hotp := NewHotp()
+	testCases := []struct {
+		counter Counter
+		window  int
+		code    string
+		valid   bool
+		matched Counter
+	}{
+		{counter: 0, window: 0, code: "755224", valid: true, matched: 0},
+		{counter: 0, window: 0, code: "287082", valid: false, matched: 0},
+		{counter: 0, window: 3, code: "969429", valid: true, matched: 3},
+		{counter: 2, window: 3, code: "254676", valid: true, matched: 5},
+		{counter: 2, window: 3, code: "287922", valid: false, matched: 0},
+		{counter: 2, window: 3, code: "287082", valid: false, matched: 0},
+		{counter: 1, window: -1, code: "287082", valid: true, matched: 1},
+		{counter: 1, window: -1, code: "359152", valid: false, matched: 0},
+	}
+	for _, tC := range testCases {
+		t.Run("RFC 4226 section 7.4 - Resynchronization of the Counter", func(t *testing.T) {
+			valid, matched := hotp.ValidateLookAhead(key, tC.code, tC.counter, tC.window)
+			if valid != tC.valid || matched != tC.matched {
+				t.Logf("Expected (%t, %d), but was (%t, %d)", tC.valid, tC.matched, valid, matched)
+				t.Fail()
+			}
+		})
+	}
+}
+
+func TestDigitsRange(t *testing.T) {
+	key := []byte("12345678901234567890")
+	testCases := []struct {
+		digits  int
+		clamped int
+	}{
+		{digits: -1, clamped: 6},
+		{digits: 0, clamped: 6},
+		{digits: 5, clamped: 6},
+		{digits: 11, clamped: 10},
+		{digits: 20, clamped: 10},
+	}
+	for _, tC := range testCases {
+		t.Run("Digits out of range", func(t *testing.T) {
+			expected := NewHotp(WithDigits(tC.clamped)).Generate(key, 1)
+			if code := NewHotp(WithDigits(tC.digits)).Generate(key, 1); code != expected {
+				t.Logf("Expected code %s for %d digits, but was %s", expected, tC.digits, code)
+				t.Fail()
+			}
+			if code := NewTotp(WithDigits(tC.digits)).Code(key, time.Unix(59, 0)); len(code) != tC.clamped {
+				t.Logf("Expected %d digits, but was %s", tC.clamped, code)
+				t.Fail()
+			}
+			if _, err := NewHotpE(WithDigits(tC.digits)); !errors.Is(err, ErrDigits) {
+				t.Logf("Expected %v, but was %v", ErrDigits, err)
+				t.Fail()
+			}
+			if _, err := NewTotpE(WithDigits(tC.digits)); !errors.Is(err, ErrDigits) {
+				t.Logf("Expected %v, but was %v", ErrDigits, err)
+				t.Fail()
+			}
+		})
+	}
+	for digits := minDigits; digits <= maxDigits; digits++ {
+		if _, err := NewHotpE(WithDigits(digits)); err != nil {
+			t.Logf("Expected no error for %d digits, but was %v", digits, err)
+			t.Fail()
+		}
+	}
+}
+
+func TestSubSecondTimeStep(t *testing.T) {
+	testCases := []struct {
+		step    time.Duration
+		unix    time.Time
+		counter Counter
+	}{
+		{step: 500 * time.Millisecond, unix: time.Unix(1, 0), counter: 2},
+		{step: 500 * time.Millisecond, unix: time.Unix(1, 499999999), counter: 2},
+		{step: 500 * time.Millisecond, unix: time.Unix(1, 500000000), counter: 3},
+		{step: 1500 * time.Millisecond, unix: time.Unix(2, 999999999), counter: 1},
+		{step: 1500 * time.Millisecond, unix: time.Unix(3, 0), counter: 2},
+		{step: 30 * time.Second, unix: time.Unix(20000000000, 999999999), counter: 666666666},
+	}
+	for _, tC := range testCases {
+		t.Run("Time step with nanosecond resolution", func(t *testing.T) {
+			totp := NewTotp(WithTimeStep(tC.step))
+			if c := totp.At(tC.unix); c != tC.counter {
+				t.Logf("Expected %d, but was %d", tC.counter, c)
+				t.Fail()
+			}
+		})
+	}
+}
+
+func TestTimeStepRange(t *testing.T) {
+	for _, step := range []time.Duration{-time.Second, 0, time.Microsecond} {
+		if _, err := NewTotpE(WithTimeStep(step)); !errors.Is(err, ErrTimeStep) {
+			t.Logf("Expected %v, but was %v", ErrTimeStep, err)
+			t.Fail()
+		}
+		if _, err := NewTotpE(WithPeriod(step)); !errors.Is(err, ErrTimeStep) {
+			t.Logf("Expected %v, but was %v", ErrTimeStep, err)
+			t.Fail()
+		}
+		totp := NewTotp(WithTimeStep(step))
+		if c := totp.At(time.Unix(1, 0)); c != 1000 {
+			t.Logf("Expected %d, but was %d", 1000, c)
+			t.Fail()
+		}
+	}
+	if totp := NewTotp(WithPeriod(time.Minute)); totp.TimeStep() != time.Minute {
+		t.Logf("Expected %v, but was %v", time.Minute, totp.TimeStep())
+		t.Fail()
+	}
+}
+
+func TestValidateMismatch(t *testing.T) {
+	key := []byte("12345678901234567890")
+	hotp := NewHotp()
+	for _, code := range []string{"", "755", "755225", "7552240", "155224"} {
+		if hotp.Validate(key, code, 0) {
+			t.Logf("Code %s expected to be invalid", code)
+			t.Fail()
+		}
+	}
+}
+
+func TestAlphabet(t *testing.T) {
+	key := []byte("12345678901234567890")
+	testCases := []struct {
+		alphabet string
+		digits   int
+		code     string
+	}{
+		// The truncated value of the counter 0 is 0x4c93cf18 (1284755224)
+		{alphabet: "0123456789", digits: 6, code: "755224"},
+		{alphabet: "0123456789", digits: 9, code: "284755224"},
+		{alphabet: "0123456789ABCDEF", digits: 7, code: "C93CF18"},
+		{alphabet: "0123456789abcdef", digits: 4, code: "cf18"},
+		{alphabet: "01", digits: 31, code: "1001100100100111100111100011000"},
+		{alphabet: "ABCDEFGHIJKLMNOPQRSTUVWXYZ234567", digits: 6, code: "GJHTYY"},
+	}
+	for _, tC := range testCases {
+		t.Run("Custom alphabet", func(t *testing.T) {
+			hotp, err := NewHotpE(WithAlphabet(tC.alphabet), WithDigits(tC.digits))
+			if err != nil {
+				t.Logf("Expected no error, but was %v", err)
+				t.FailNow()
+			}
+			code := hotp.Generate(key, 0)
+			if code != tC.code {
+				t.Logf("Expected code %s, but was %s", tC.code, code)
+				t.Fail()
+			}
+			if !hotp.Validate(key, code, 0) {
+				t.Logf("Code %s expected to be valid", code)
+				t.Fail()
+			}
+		})
+	}
+}
+
+func TestAlphabetPadding(t *testing.T) {
+	key20 := []byte("12345678901234567890")
+	testCases := []struct {
+		hotp    *hotp
+		counter Counter
+		code    string
+	}{
+		{hotp: NewHotp(WithAlphabet("ABCDEFGHIJKLMNOPQRSTUVWXYZ234567")), counter: 1, code: "ATS7XK"},
+		{hotp: NewHotp(WithAlphabet("ABCDEFGHIJKLMNOPQRSTUVWXYZ234567"), WithModulus(10)), counter: 0, code: "AAAAAE"},
+		{hotp: NewHotp(WithAlphabet("xy"), WithDigits(8), WithModulus(2)), counter: 0, code: "xxxxxxxx"},
+		{hotp: NewHotp(WithModulus(1000)), counter: 0, code: "000224"},
+	}
+	for _, tC := range testCases {
+		t.Run("Padding with the first symbol", func(t *testing.T) {
+			if code := tC.hotp.Generate(key20, tC.counter); code != tC.code {
+				t.Logf("Expected code %s, but was %s", tC.code, code)
+				t.Fail()
+			}
+		})
+	}
+}
+
+func TestAlphabetInvalid(t *testing.T) {
+	for _, alphabet := range []string{"", "0", "00", "0120", "01ü"} {
+		if _, err := NewHotpE(WithAlphabet(alphabet)); !errors.Is(err, ErrAlphabet) {
+			t.Logf("Expected %v, but was %v", ErrAlphabet, err)
+			t.Fail()
+		}
+		if code := NewHotp(WithAlphabet(alphabet)).Generate([]byte("12345678901234567890"), 0); code != "755224" {
+			t.Logf("Expected fallback to decimal code %s, but was %s", "755224", code)
+			t.Fail()
+		}
+	}
+	testCases := []struct {
+		alphabet string
+		digits   int
+		clamped  int
+	}{
+		{alphabet: "01", digits: 32, clamped: 31},
+		{alphabet: "0123456789ABCDEF", digits: 9, clamped: 7},
+		{alphabet: "0123456789ABCDEF", digits: 0, clamped: 1},
+	}
+	for _, tC := range testCases {
+		if _, err := NewHotpE(WithAlphabet(tC.alphabet), WithDigits(tC.digits)); !errors.Is(err, ErrDigits) {
+			t.Logf("Expected %v, but was %v", ErrDigits, err)
+			t.Fail()
+		}
+		code := NewHotp(WithAlphabet(tC.alphabet), WithDigits(tC.digits)).Generate([]byte("12345678901234567890"), 0)
+		if len(code) != tC.clamped {
+			t.Logf("Expected %d symbols, but was %s", tC.clamped, code)
+			t.Fail()
+		}
+	}
+}
+
+func TestChecksum(t *testing.T) {
+	key20 := []byte("12345678901234567890")
+	testCases := []struct {
+		counter Counter
+		code    string
+	}{
+		{counter: 0, code: "7552243"},
+		{counter: 1, code: "2870822"},
+		{counter: 2, code: "3591526"},
+		{counter: 3, code: "9694290"},
+		{counter: 4, code: "3383148"},
+		{counter: 5, code: "2546760"},
+		{counter: 6, code: "2879229"},
+		{counter: 7, code: "1625839"},
+		{counter: 8, code: "3998713"},
+		{counter: 9, code: "5204896"},
+	}
+	for _, tC := range testCases {
+		t.Run("RFC 4226 section 5.5 - Checksum digit", func(t *testing.T) {
+			hotp := NewHotp(WithChecksum(true))
+			code := hotp.Generate(key20, tC.counter)
+			if tC.code != code {
+				t.Logf("Expected code %s, but was %s", tC.code, code)
+				t.Fail()
+			}
+			if !hotp.Validate(key20, code, tC.counter) {
+				t.Logf("Code %s expected to be valid", code)
+				t.Fail()
+			}
+			typo := code[:1] + string('0'+(code[1]-'0'+1)%10) + code[2:]
+			if hotp.Validate(key20, typo, tC.counter) {
+				t.Logf("Code %s expected to be invalid", typo)
+				t.Fail()
+			}
+			if bare := code[:len(code)-1]; !hotp.Validate(key20, bare, tC.counter) {
+				t.Logf("Code %s without checksum digit expected to be valid", bare)
+				t.Fail()
+			}
+			if bare := typo[:len(typo)-1]; hotp.Validate(key20, bare, tC.counter) {
+				t.Logf("Code %s without checksum digit expected to be invalid", bare)
+				t.Fail()
+			}
+		})
+	}
+}
+
+func TestChecksumAlphabet(t *testing.T) {
+	if _, err := NewHotpE(WithAlphabet("0123456789ABCDEF"), WithDigits(6), WithChecksum(true)); !errors.Is(err, ErrChecksum) {
+		t.Logf("Expected %v, but was %v", ErrChecksum, err)
+		t.Fail()
+	}
+	hotp := NewHotp(WithAlphabet("0123456789ABCDEF"), WithDigits(6), WithChecksum(true))
+	if code := hotp.Generate([]byte("12345678901234567890"), 0); code != "93CF18" {
+		t.Logf("Expected code %s without checksum, but was %s", "93CF18", code)
+		t.Fail()
+	}
+}
+
+func TestTruncationOffset(t *testing.T) {
+	key20 := []byte("12345678901234567890")
+	testCases := []struct {
+		counter Counter
+		offset  int
+		code    string
+	}{
+		{counter: 0, offset: -1, code: "755224"},
+		{counter: 0, offset: 4, code: "455891"},
+		{counter: 0, offset: 16, code: "240304"},
+		{counter: 1, offset: 0, code: "717529"},
+		{counter: 1, offset: 4, code: "647552"},
+		{counter: 2, offset: 16, code: "616004"},
+	}
+	for _, tC := range testCases {
+		t.Run("RFC 4226 appendix A - Fixed truncation offset", func(t *testing.T) {
+			hotp, err := NewHotpE(WithTruncationOffset(tC.offset))
+			if err != nil {
+				t.Logf("Expected no error, but was %v", err)
+				t.FailNow()
+			}
+			if code := hotp.Generate(key20, tC.counter); code != tC.code {
+				t.Logf("Expected code %s, but was %s", tC.code, code)
+				t.Fail()
+			}
+		})
+	}
+	if _, err := NewHotpE(WithTruncationOffset(17)); !errors.Is(err, ErrTruncationOffset) {
+		t.Logf("Expected %v, but was %v", ErrTruncationOffset, err)
+		t.Fail()
+	}
+	if _, err := NewHotpE(WithHash(sha256.New), WithTruncationOffset(28)); err != nil {
+		t.Logf("Expected no error, but was %v", err)
+		t.Fail()
+	}
+	if code := NewHotp(WithTruncationOffset(17)).Generate(key20, 0); code != "755224" {
+		t.Logf("Expected dynamic truncation code %s, but was %s", "755224", code)
+		t.Fail()
+	}
+}
+
+func BenchmarkGenerate(b *testing.B) {
+	key := []byte("12345678901234567890")
+	hotp := NewHotp()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		hotp.Generate(key, Counter(i))
+	}
+}
+
+func BenchmarkGenerateInto(b *testing.B) {
+	key := []byte("12345678901234567890")
+	hotp := NewHotp()
+	var buf [6]byte
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		hotp.GenerateInto(buf[:], key, Counter(i))
+	}
+}
+
+func BenchmarkGenerateIntoUnpooled(b *testing.B) {
+	key := []byte("12345678901234567890")
+	hotp := NewHotp(WithHash(func() hash.Hash { return sha1.New() }))
+	var buf [6]byte
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		hotp.GenerateInto(buf[:], key, Counter(i))
+	}
+}
+
+func BenchmarkGenerateAppend(b *testing.B) {
+	key := []byte("12345678901234567890")
+	hotp := NewHotp()
+	buf := make([]byte, 0, 6)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		buf, _ = hotp.GenerateAppend(buf[:0], key, Counter(i))
+	}
+}
+
+func BenchmarkValidate(b *testing.B) {
+	key := []byte("12345678901234567890")
+	hotp := NewHotp()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		hotp.Validate(key, "000000", Counter(i))
+	}
+}
+
+func BenchmarkValidateAtSkew(b *testing.B) {
+	key := []byte("12345678901234567890")
+	totp := NewTotp(WithSkew(2))
+	now := time.Unix(1111111109, 0)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		totp.ValidateAt(key, "000000", now)
+	}
+}
+
+func BenchmarkValidateAtSkewWithoutReuse(b *testing.B) {
+	key := []byte("12345678901234567890")
+	hotp := NewHotp()
+	totp := NewTotp()
+	now := time.Unix(1111111109, 0)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		for offset := -2; offset <= 2; offset++ {
+			hotp.Validate(key, "000000", totp.At(now)+Counter(offset))
+		}
+	}
+}
+
+func BenchmarkValidateLookAhead(b *testing.B) {
+	key := []byte("12345678901234567890")
+	hotp := NewHotp()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		hotp.ValidateLookAhead(key, "000000", 0, 10)
+	}
+}
+
+func TestGenerateInt(t *testing.T) {
+	key20 := []byte("12345678901234567890")
+	testCases := []struct {
+		hotp  *hotp
+		value int64
+	}{
+		{hotp: NewHotp(), value: 755224},
+		{hotp: NewHotp(WithDigits(9)), value: 284755224},
+		{hotp: NewHotp(WithChecksum(true)), value: 7552243},
+		{hotp: NewHotp(WithAlphabet("0123456789ABCDEF"), WithDigits(7)), value: 0xC93CF18},
+	}
+	for _, tC := range testCases {
+		t.Run("Numeric code value", func(t *testing.T) {
+			if value := tC.hotp.GenerateInt(key20, 0); value != tC.value {
+				t.Logf("Expected %d, but was %d", tC.value, value)
+				t.Fail()
+			}
+		})
+	}
+	hotp := NewHotp(WithDigits(8))
+	for counter := Counter(0); counter < 100; counter++ {
+		code := hotp.Generate(key20, counter)
+		if value := hotp.GenerateInt(key20, counter); fmt.Sprintf("%08d", value) != code {
+			t.Logf("Expected %s, but was %d", code, value)
+			t.Fail()
+		}
+	}
+}
+
+func TestLenientInput(t *testing.T) {
+	key20 := []byte("12345678901234567890")
+	testCases := []struct {
+		code  string
+		valid bool
+	}{
+		{code: "755224", valid: true},
+		{code: "755 224", valid: true},
+		{code: "755-224", valid: true},
+		{code: " 75 52 24 ", valid: true},
+		{code: "755 22", valid: false},
+		{code: "755 2244", valid: false},
+		{code: "755_224", valid: false},
+	}
+	for _, tC := range testCases {
+		t.Run("Codes with separators", func(t *testing.T) {
+			hotp := NewHotp(WithLenientInput(true))
+			if valid := hotp.Validate(key20, tC.code, 0); valid != tC.valid {
+				t.Logf("Expected %t for %q, but was %t", tC.valid, tC.code, valid)
+				t.Fail()
+			}
+			if valid, _ := hotp.ValidateLookAhead(key20, tC.code, 0, 1); valid != tC.valid {
+				t.Logf("Expected %t for %q, but was %t", tC.valid, tC.code, valid)
+				t.Fail()
+			}
+			totp := NewTotp(WithLenientInput(true))
+			if valid := totp.ValidateAt(key20, tC.code, time.Unix(0, 0)); valid != tC.valid {
+				t.Logf("Expected %t for %q, but was %t", tC.valid, tC.code, valid)
+				t.Fail()
+			}
+		})
+	}
+	if NewHotp().Validate(key20, "755 224", 0) {
+		t.Logf("Code with separators expected to be invalid by default")
+		t.Fail()
+	}
+}
+
+func TestNumericCompare(t *testing.T) {
+	key20 := []byte("12345678901234567890")
+	at := time.Unix(1111111109, 0)
+	testCases := []struct {
+		code  string
+		valid bool
+	}{
+		{code: "081804", valid: true},
+		{code: "81804", valid: true},
+		{code: "0081804", valid: true},
+		{code: "1804", valid: false},
+		{code: "181804", valid: false},
+		{code: "+81804", valid: false},
+		{code: "", valid: false},
+	}
+	for _, tC := range testCases {
+		t.Run("Numeric codes", func(t *testing.T) {
+			totp := NewTotp(WithNumericCompare(true))
+			if valid := totp.ValidateAt(key20, tC.code, at); valid != tC.valid {
+				t.Logf("Expected %t for %q, but was %t", tC.valid, tC.code, valid)
+				t.Fail()
+			}
 		})
 	}
+	if NewTotp().ValidateAt(key20, "81804", at) {
+		t.Logf("Code without leading zeros expected to be invalid by default")
+		t.Fail()
+	}
+	hex := NewHotp(WithAlphabet("0123456789ABCDEF"), WithDigits(7), WithNumericCompare(true))
+	if hex.Validate(key20, "0C93CF18", 0) {
+		t.Logf("Numeric compare expected to have no effect with custom alphabets")
+		t.Fail()
+	}
+}
+
+func TestFormatCode(t *testing.T) {
+	testCases := []struct {
+		code      string
+		groupSize int
+		sep       string
+		formatted string
+	}{
+		{code: "123456", groupSize: 3, sep: " ", formatted: "123 456"},
+		{code: "12345678", groupSize: 4, sep: "-", formatted: "1234-5678"},
+		{code: "1234567", groupSize: 3, sep: " ", formatted: "123 456 7"},
+		{code: "123456", groupSize: 2, sep: "", formatted: "123456"},
+		{code: "123456", groupSize: 6, sep: " ", formatted: "123456"},
+		{code: "123456", groupSize: 10, sep: " ", formatted: "123456"},
+		{code: "123456", groupSize: 0, sep: " ", formatted: "123456"},
+		{code: "", groupSize: 3, sep: " ", formatted: ""},
+	}
+	for _, tC := range testCases {
+		t.Run("Grouped code", func(t *testing.T) {
+			if formatted := FormatCode(tC.code, tC.groupSize, tC.sep); formatted != tC.formatted {
+				t.Logf("Expected %q, but was %q", tC.formatted, formatted)
+				t.Fail()
+			}
+		})
+	}
+	key20 := []byte("12345678901234567890")
+	hotp := NewHotp(WithLenientInput(true))
+	if code := FormatCode(hotp.Generate(key20, 0), 3, " "); !hotp.Validate(key20, code, 0) {
+		t.Logf("Code %s expected to be valid", code)
+		t.Fail()
+	}
+}
+
+func TestGenerateRange(t *testing.T) {
+	key20 := []byte("12345678901234567890")
+	expected := []string{"359152", "969429", "338314", "254676"}
+	codes := NewHotp().GenerateRange(key20, 2, 4)
+	if len(codes) != len(expected) {
+		t.Logf("Expected %v, but was %v", expected, codes)
+		t.FailNow()
+	}
+	for i := range codes {
+		if codes[i] != expected[i] {
+			t.Logf("Expected %v, but was %v", expected, codes)
+			t.Fail()
+		}
+	}
+	if codes := NewHotp().GenerateRange(key20, 2, 0); len(codes) != 0 {
+		t.Logf("Expected no codes, but was %v", codes)
+		t.Fail()
+	}
+}
+
+func TestClone(t *testing.T) {
+	key20 := []byte("12345678901234567890")
+	base := NewTotp(WithSkew(1))
+	clone := base.Clone(WithDigits(8), WithSkew(0))
+	if code := base.Code(key20, time.Unix(59, 0)); code != "287082" {
+		t.Logf("Expected the base unchanged with code %s, but was %s", "287082", code)
+		t.Fail()
+	}
+	if code := clone.Code(key20, time.Unix(59, 0)); code != "94287082" {
+		t.Logf("Expected code %s, but was %s", "94287082", code)
+		t.Fail()
+	}
+	if base.skew != 1 || clone.skew != 0 {
+		t.Logf("Expected skews 1 and 0, but was %d and %d", base.skew, clone.skew)
+		t.Fail()
+	}
+	if clone := NewHotp().Clone(WithDigits(20)); clone.digits != maxDigits {
+		t.Logf("Expected %d digits, but was %d", maxDigits, clone.digits)
+		t.Fail()
+	}
+	if clone := NewHotp(WithDigits(7)).Clone(); clone.digits != 7 {
+		t.Logf("Expected %d digits, but was %d", 7, clone.digits)
+		t.Fail()
+	}
+}
+
+func TestValidateE(t *testing.T) {
+	key20 := []byte("12345678901234567890")
+	testCases := []struct {
+		desc     string
+		hotp     *hotp
+		code     string
+		valid    bool
+		expected error
+	}{
+		{desc: "valid", hotp: NewHotp(), code: "755224", valid: true},
+		{desc: "mismatch", hotp: NewHotp(), code: "755225", expected: ErrCodeMismatch},
+		{desc: "short", hotp: NewHotp(), code: "75522", expected: ErrCodeLength},
+		{desc: "long", hotp: NewHotp(), code: "7552240", expected: ErrCodeLength},
+		{desc: "letters", hotp: NewHotp(), code: "75522a", expected: ErrCodeFormat},
+		{desc: "separators", hotp: NewHotp(), code: "755 22", expected: ErrCodeFormat},
+		{desc: "lenient", hotp: NewHotp(WithLenientInput(true)), code: "755 224", valid: true},
+		{desc: "checksum", hotp: NewHotp(WithChecksum(true)), code: "7552245", expected: ErrCodeFormat},
+		{desc: "checksum length", hotp: NewHotp(WithChecksum(true)), code: "75522", expected: ErrCodeLength},
+		{desc: "without checksum", hotp: NewHotp(WithChecksum(true)), code: "755224", valid: true},
+		{desc: "alphabet", hotp: NewHotp(steamGuard()), code: "PV9M0", expected: ErrCodeFormat},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			valid, err := tC.hotp.ValidateE(key20, tC.code, 0)
+			if valid != tC.valid || !errors.Is(err, tC.expected) {
+				t.Logf("Expected %t and %v for %q, but was %t and %v", tC.valid, tC.expected, tC.code, valid, err)
+				t.Fail()
+			}
+		})
+	}
+}
+
+func TestValidateContext(t *testing.T) {
+	key20 := []byte("12345678901234567890")
+	ctx, cancel := context.WithCancel(context.Background())
+	hotp := NewHotp()
+	if ok, matched, err := hotp.ValidateLookAheadContext(ctx, key20, "338314", 0, 10); !ok || matched != 4 || err != nil {
+		t.Logf("Expected match at %d, but was %t, %d, %v", 4, ok, matched, err)
+		t.Fail()
+	}
+	totp := NewTotp(WithSkew(1))
+	if ok, offset, err := totp.ValidateOffsetContext(ctx, key20, "287082", time.Unix(89, 0)); !ok || offset != -1 || err != nil {
+		t.Logf("Expected match at offset %d, but was %t, %d, %v", -1, ok, offset, err)
+		t.Fail()
+	}
+	cancel()
+	if ok, _, err := hotp.ValidateLookAheadContext(ctx, key20, "338314", 0, 10); ok || !errors.Is(err, context.Canceled) {
+		t.Logf("Expected %v, but was %t, %v", context.Canceled, ok, err)
+		t.Fail()
+	}
+	if ok, _, err := totp.ValidateOffsetContext(ctx, key20, "287082", time.Unix(89, 0)); ok || !errors.Is(err, context.Canceled) {
+		t.Logf("Expected %v, but was %t, %v", context.Canceled, ok, err)
+		t.Fail()
+	}
+}
+
+func TestLookAheadLimit(t *testing.T) {
+	key20 := []byte("12345678901234567890")
+	hotp := NewHotp()
+	if ok, matched := hotp.ValidateLookAhead(key20, "338314", 0, math.MaxInt); !ok || matched != 4 {
+		t.Logf("Expected match at %d, but was %t, %d", 4, ok, matched)
+		t.Fail()
+	}
+	code := hotp.Generate(key20, maxLookAhead+1)
+	if ok, _ := hotp.ValidateLookAhead(key20, code, 0, math.MaxInt); ok {
+		t.Logf("Code %s expected to be rejected beyond the clamped window", code)
+		t.Fail()
+	}
+}
+
+func TestValidateWindowE(t *testing.T) {
+	key20 := []byte("12345678901234567890")
+	hotp := NewHotp()
+	if ok, matched, err := hotp.ValidateLookAheadE(key20, "338314", 0, 10); !ok || matched != 4 || err != nil {
+		t.Logf("Expected match at %d, but was %t, %d, %v", 4, ok, matched, err)
+		t.Fail()
+	}
+	if ok, _, err := hotp.ValidateLookAheadE(key20, "338314", 0, 3); ok || !errors.Is(err, ErrOutsideWindow) {
+		t.Logf("Expected %v, but was %t, %v", ErrOutsideWindow, ok, err)
+		t.Fail()
+	}
+	if ok, _, err := hotp.ValidateLookAheadE(key20, "338314", 0, maxLookAhead+1); ok || !errors.Is(err, ErrWindow) {
+		t.Logf("Expected %v, but was %t, %v", ErrWindow, ok, err)
+		t.Fail()
+	}
+	if ok, _, err := hotp.ValidateLookAheadE(key20, "33831", 0, 10); ok || !errors.Is(err, ErrCodeLength) {
+		t.Logf("Expected %v, but was %t, %v", ErrCodeLength, ok, err)
+		t.Fail()
+	}
+	totp := NewTotp(WithSkew(1))
+	testCases := []struct {
+		code     string
+		time     time.Time
+		expected error
+	}{
+		{code: "287082", time: time.Unix(89, 0), expected: nil},
+		{code: "287082", time: time.Unix(90, 0), expected: ErrOutsideWindow},
+		{code: "287082", time: time.Unix(-1, 0), expected: ErrOutsideWindow},
+		{code: "28708x", time: time.Unix(59, 0), expected: ErrCodeFormat},
+		{code: "2870821", time: time.Unix(59, 0), expected: ErrCodeLength},
+	}
+	for _, tC := range testCases {
+		if ok, err := totp.ValidateAtE(key20, tC.code, tC.time); ok != (tC.expected == nil) || !errors.Is(err, tC.expected) {
+			t.Logf("Expected %v for %q at %v, but was %t, %v", tC.expected, tC.code, tC.time.Unix(), ok, err)
+			t.Fail()
+		}
+	}
+}
+
+func TestTimeStepAndEpoch(t *testing.T) {
+	totp := NewTotp()
+	if totp.TimeStep() != 30*time.Second || totp.Epoch() != 0 {
+		t.Logf("Expected defaults, but was %v and %d", totp.TimeStep(), totp.Epoch())
+		t.Fail()
+	}
+	totp = NewTotp(WithTimeStep(1500*time.Millisecond), WithEpoch(100))
+	if totp.TimeStep() != 1500*time.Millisecond || totp.Epoch() != 100 {
+		t.Logf("Expected %v and %d, but was %v and %d", 1500*time.Millisecond, 100, totp.TimeStep(), totp.Epoch())
+		t.Fail()
+	}
+}
+
+func TestValidateAny(t *testing.T) {
+	key20 := []byte("12345678901234567890")
+	key32 := []byte("12345678901234567890123456789012")
+	hotp := NewHotp()
+	testCases := []struct {
+		keys  [][]byte
+		code  string
+		valid bool
+		index int
+	}{
+		{keys: [][]byte{key32, key20}, code: "755224", valid: true, index: 1},
+		{keys: [][]byte{key20, key32}, code: "755224", valid: true, index: 0},
+		{keys: [][]byte{key20, key20}, code: "755224", valid: true, index: 0},
+		{keys: [][]byte{key20, key32}, code: "000000", valid: false, index: -1},
+		{keys: nil, code: "755224", valid: false, index: -1},
+	}
+	for _, tC := range testCases {
+		t.Run("Candidate keys", func(t *testing.T) {
+			if valid, index := hotp.ValidateAny(tC.keys, tC.code, 0); valid != tC.valid || index != tC.index {
+				t.Logf("Expected %t and %d, but was %t and %d", tC.valid, tC.index, valid, index)
+				t.Fail()
+			}
+		})
+	}
+}
+
+func TestValidateBatch(t *testing.T) {
+	key20 := []byte("12345678901234567890")
+	key32 := []byte("12345678901234567890123456789012")
+	hotp := NewHotp()
+	entries := []Entry{{Key: key32, Counter: 0}, {Key: key20, Counter: 5}, {Key: key20, Counter: 1}, {Key: key20, Counter: 1}}
+	testCases := []struct {
+		code  string
+		valid bool
+		index int
+	}{
+		{code: "287082", valid: true, index: 2},
+		{code: "254676", valid: true, index: 1},
+		{code: "755224", valid: false, index: -1},
+		{code: "28708", valid: false, index: -1},
+	}
+	for _, tC := range testCases {
+		t.Run("Batch entries", func(t *testing.T) {
+			if valid, index := hotp.ValidateBatch(entries, tC.code); valid != tC.valid || index != tC.index {
+				t.Logf("Expected %t and %d, but was %t and %d", tC.valid, tC.index, valid, index)
+				t.Fail()
+			}
+		})
+	}
+	if valid, index := hotp.ValidateBatch(nil, "287082"); valid || index != -1 {
+		t.Logf("Expected false and -1, but was %t and %d", valid, index)
+		t.Fail()
+	}
+}
+
+func TestValidateAlgorithms(t *testing.T) {
+	key20 := []byte("12345678901234567890")
+	hotp := NewHotp()
+	sha256Code := NewHotp(WithHash(sha256.New)).Generate(key20, 0)
+	testCases := []struct {
+		hashes []func() hash.Hash
+		code   string
+		valid  bool
+		index  int
+	}{
+		{hashes: []func() hash.Hash{sha1.New, sha256.New}, code: "755224", valid: true, index: 0},
+		{hashes: []func() hash.Hash{sha1.New, sha256.New}, code: sha256Code, valid: true, index: 1},
+		{hashes: []func() hash.Hash{sha512.New, sha1.New, sha1.New}, code: "755224", valid: true, index: 1},
+		{hashes: []func() hash.Hash{sha256.New, sha512.New}, code: "755224", valid: false, index: -1},
+		{hashes: []func() hash.Hash{shortSHA1(4), sha1.New}, code: "755224", valid: true, index: 1},
+		{hashes: nil, code: "755224", valid: false, index: -1},
+		{hashes: []func() hash.Hash{sha1.New}, code: "75522", valid: false, index: -1},
+	}
+	for _, tC := range testCases {
+		t.Run("Candidate hash functions", func(t *testing.T) {
+			if valid, index := hotp.ValidateAlgorithms(key20, tC.code, 0, tC.hashes...); valid != tC.valid || index != tC.index {
+				t.Logf("Expected %t and %d, but was %t and %d", tC.valid, tC.index, valid, index)
+				t.Fail()
+			}
+		})
+	}
+}
+func TestString(t *testing.T) {
+	testCases := []struct {
+		value    fmt.Stringer
+		expected string
+	}{
+		{value: NewHotp(), expected: "HOTP(digits=6, hash=SHA1)"},
+		{value: NewHotp(WithHash(func() hash.Hash { return sha512.New384() })), expected: "HOTP(digits=6, hash=unknown)"},
+		{value: NewTotp(WithDigits(8), WithHash(sha256.New)), expected: "TOTP(digits=8, hash=SHA256, step=30s, epoch=0)"},
+		{value: NewTotp(WithTimeStep(1500*time.Millisecond), WithEpoch(100)), expected: "TOTP(digits=6, hash=SHA1, step=1.5s, epoch=100)"},
+		{value: NewHotp(WithLabel("alice")), expected: "HOTP(label=alice, digits=6, hash=SHA1)"},
+	}
+	for _, tC := range testCases {
+		if s := tC.value.String(); s != tC.expected {
+			t.Logf("Expected %s, but was %s", tC.expected, s)
+			t.Fail()
+		}
+	}
+	if s := fmt.Sprint(NewHotp()); s != "HOTP(digits=6, hash=SHA1)" {
+		t.Logf("Expected %s, but was %s", "HOTP(digits=6, hash=SHA1)", s)
+		t.Fail()
+	}
+}
+
+func TestValidateDigits(t *testing.T) {
+	key20 := []byte("12345678901234567890")
+	testCases := []struct {
+		hotp    *hotp
+		code    string
+		lengths []int
+		valid   bool
+	}{
+		{hotp: NewHotp(WithDigits(8)), code: "84755224", lengths: []int{6, 8}, valid: true},
+		{hotp: NewHotp(WithDigits(8)), code: "755224", lengths: []int{6, 8}, valid: true},
+		{hotp: NewHotp(WithDigits(8)), code: "4755224", lengths: []int{6, 8}, valid: false},
+		{hotp: NewHotp(WithDigits(8)), code: "755224", lengths: []int{8}, valid: false},
+		{hotp: NewHotp(), code: "755224", lengths: nil, valid: false},
+		{hotp: NewHotp(), code: "24", lengths: []int{2, 6}, valid: false},
+		{hotp: NewHotp(WithChecksum(true)), code: "755224", lengths: []int{6}, valid: false},
+	}
+	for _, tC := range testCases {
+		t.Run("Codes of several lengths", func(t *testing.T) {
+			if valid := tC.hotp.ValidateDigits(key20, tC.code, 0, tC.lengths...); valid != tC.valid {
+				t.Logf("Expected %t for %q, but was %t", tC.valid, tC.code, valid)
+				t.Fail()
+			}
+		})
+	}
+	hotp := NewHotp(WithChecksum(true))
+	code := NewHotp(WithChecksum(true), WithDigits(8)).Generate(key20, 0)
+	if !hotp.ValidateDigits(key20, code, 0, 6, 8) {
+		t.Logf("Code %s with checksum digit expected to be valid", code)
+		t.Fail()
+	}
+}
+
+func TestValidateAuto(t *testing.T) {
+	key20 := []byte("12345678901234567890")
+	testCases := []struct {
+		code  string
+		valid bool
+	}{
+		{code: "755224", valid: true},
+		{code: "4755224", valid: true},
+		{code: "84755224", valid: true},
+		{code: "55224", valid: false},
+		{code: "284755224", valid: false},
+		{code: "84755225", valid: false},
+		{code: "", valid: false},
+	}
+	hotp := NewHotp()
+	for _, tC := range testCases {
+		t.Run("Inferred code length", func(t *testing.T) {
+			if valid := hotp.ValidateAuto(key20, tC.code, 0); valid != tC.valid {
+				t.Logf("Expected %t for %q, but was %t", tC.valid, tC.code, valid)
+				t.Fail()
+			}
+		})
+	}
+	lenient := NewHotp(WithLenientInput(true))
+	if !lenient.ValidateAuto(key20, "8475 5224", 0) {
+		t.Log("Expected the length to be inferred without separators")
+		t.Fail()
+	}
+	checksum := NewHotp(WithChecksum(true))
+	if code := NewHotp(WithChecksum(true), WithDigits(8)).Generate(key20, 0); !checksum.ValidateAuto(key20, code, 0) {
+		t.Logf("Code %s with checksum digit expected to be valid", code)
+		t.Fail()
+	}
+}
+
+func TestGenerateInto(t *testing.T) {
+	key20 := []byte("12345678901234567890")
+	testCases := []struct {
+		hotp *hotp
+		code string
+	}{
+		{hotp: NewHotp(), code: "755224"},
+		{hotp: NewHotp(WithDigits(8)), code: "84755224"},
+		{hotp: NewHotp(WithChecksum(true)), code: "7552243"},
+		{hotp: NewHotp(steamGuard()), code: NewHotp(steamGuard()).Generate(key20, 0)},
+	}
+	for _, tC := range testCases {
+		t.Run("Code into buffer", func(t *testing.T) {
+			buf := make([]byte, 16)
+			n, err := tC.hotp.GenerateInto(buf, key20, 0)
+			if err != nil || string(buf[:n]) != tC.code {
+				t.Logf("Expected code %s, but was %s (%v)", tC.code, buf[:n], err)
+				t.Fail()
+			}
+		})
+	}
+	if n, err := NewHotp().GenerateInto(make([]byte, 5), key20, 0); n != 0 || !errors.Is(err, io.ErrShortBuffer) {
+		t.Logf("Expected %v, but was %d, %v", io.ErrShortBuffer, n, err)
+		t.Fail()
+	}
+}
+
+func TestGenerateIntoAllocs(t *testing.T) {
+	key20 := []byte("12345678901234567890")
+	hotp := NewHotp()
+	var buf [6]byte
+	into := testing.AllocsPerRun(100, func() { hotp.GenerateInto(buf[:], key20, 0) })
+	dst := make([]byte, 0, 6)
+	appended := testing.AllocsPerRun(100, func() { dst, _ = hotp.GenerateAppend(dst[:0], key20, 0) })
+	// Hash functions other than the standard constructors are not pooled
+	unpooled := NewHotp(WithHash(func() hash.Hash { return sha1.New() }))
+	keyed := testing.AllocsPerRun(100, func() { unpooled.GenerateInto(buf[:], key20, 0) })
+	// The race detector drops pooled items at random, so allocations are
+	// compared rather than expected to be 0.
+	if into >= keyed || appended >= keyed {
+		t.Logf("Expected fewer allocations than %v with a new HMAC, but was %v and %v", keyed, into, appended)
+		t.Fail()
+	}
+}
+
+func TestGenerateAppend(t *testing.T) {
+	key20 := []byte("12345678901234567890")
+	dst, err := NewHotp().GenerateAppend([]byte("code: "), key20, 0)
+	if err != nil || string(dst) != "code: 755224" {
+		t.Logf("Expected %q, but was %q (%v)", "code: 755224", dst, err)
+		t.Fail()
+	}
+	dst, err = NewHotp(WithChecksum(true)).GenerateAppend(dst[:0], key20, 0)
+	if err != nil || string(dst) != "7552243" {
+		t.Logf("Expected %q, but was %q (%v)", "7552243", dst, err)
+		t.Fail()
+	}
+	dst, err = NewHotp(WithHash(shortSHA1(4))).GenerateAppend(dst[:0], key20, 0)
+	if !errors.Is(err, ErrDigestSize) || len(dst) != 0 {
+		t.Logf("Expected %v with dst unchanged, but was %v (%q)", ErrDigestSize, err, dst)
+		t.Fail()
+	}
+}
+
+func TestValidateInRange(t *testing.T) {
+	key20 := []byte("12345678901234567890")
+	totp := NewTotp()
+	testCases := []struct {
+		desc  string
+		code  string
+		from  time.Time
+		to    time.Time
+		valid bool
+		start time.Time
+	}{
+		{desc: "inside", code: "287082", from: time.Unix(0, 0), to: time.Unix(300, 0), valid: true, start: time.Unix(30, 0)},
+		{desc: "edges", code: "287082", from: time.Unix(59, 0), to: time.Unix(59, 0), valid: true, start: time.Unix(30, 0)},
+		{desc: "before", code: "287082", from: time.Unix(60, 0), to: time.Unix(300, 0), valid: false},
+		{desc: "after", code: "287082", from: time.Unix(0, 0), to: time.Unix(29, 0), valid: false},
+		{desc: "reversed", code: "287082", from: time.Unix(300, 0), to: time.Unix(0, 0), valid: false},
+		{desc: "before epoch", code: "755224", from: time.Unix(-100, 0), to: time.Unix(10, 0), valid: true, start: time.Unix(0, 0)},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			valid, start, err := totp.ValidateInRange(key20, tC.code, tC.from, tC.to)
+			if err != nil || valid != tC.valid || !start.Equal(tC.start) {
+				t.Logf("Expected %t at %v, but was %t at %v (%v)", tC.valid, tC.start, valid, start, err)
+				t.Fail()
+			}
+		})
+	}
+	if _, _, err := totp.ValidateInRange(key20, "287082", time.Unix(0, 0), time.Unix(30000000, 0)); !errors.Is(err, ErrRangeTooLarge) {
+		t.Logf("Expected %v, but was %v", ErrRangeTooLarge, err)
+		t.Fail()
+	}
+}
+
+func TestEqual(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		a, b     *totp
+		expected bool
+	}{
+		{desc: "defaults", a: NewTotp(), b: NewTotp(), expected: true},
+		{desc: "same hash", a: NewTotp(WithHash(sha256.New)), b: NewTotp(WithHash(func() hash.Hash { return sha256.New() })), expected: true},
+		{desc: "hash", a: NewTotp(), b: NewTotp(WithHash(sha256.New)), expected: false},
+		{desc: "unknown hash", a: NewTotp(WithHash(sha512.New384)), b: NewTotp(WithHash(sha512.New384)), expected: false},
+		{desc: "digits", a: NewTotp(), b: NewTotp(WithDigits(8)), expected: false},
+		{desc: "alphabet", a: NewTotp(), b: NewSteamTotp(), expected: false},
+		{desc: "checksum", a: NewTotp(), b: NewTotp(WithChecksum(true)), expected: false},
+		{desc: "step", a: NewTotp(), b: NewTotp(WithTimeStep(time.Minute)), expected: false},
+		{desc: "epoch", a: NewTotp(), b: NewTotp(WithEpoch(1)), expected: false},
+		{desc: "skew", a: NewTotp(), b: NewTotp(WithSkew(2)), expected: false},
+		{desc: "clock", a: NewTotp(), b: NewTotp(WithClock(fixedClock(time.Unix(0, 0)))), expected: true},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			if equal := tC.a.Equal(tC.b); equal != tC.expected {
+				t.Logf("Expected %t for %v and %v, but was %t", tC.expected, tC.a, tC.b, equal)
+				t.Fail()
+			}
+			if equal := tC.a.hotp.Equal(&tC.b.hotp); tC.expected && !equal {
+				t.Logf("Expected HOTP configurations of %v and %v to be equal", tC.a, tC.b)
+				t.Fail()
+			}
+		})
+	}
+}
+
+func TestWriteCode(t *testing.T) {
+	key20 := []byte("12345678901234567890")
+	hotp := NewHotp()
+	var b strings.Builder
+	for counter := Counter(0); counter < 3; counter++ {
+		if _, err := hotp.WriteCode(&b, key20, counter); err != nil {
+			t.Logf("Expected no error, but was %v", err)
+			t.FailNow()
+		}
+		b.WriteByte('\n')
+	}
+	if expected := "755224\n287082\n359152\n"; b.String() != expected {
+		t.Logf("Expected %q, but was %q", expected, b.String())
+		t.Fail()
+	}
+	binary := NewHotp(WithAlphabet("01"), WithDigits(31))
+	b.Reset()
+	if n, err := binary.WriteCode(&b, key20, 0); err != nil || n != 31 || b.String() != binary.Generate(key20, 0) {
+		t.Logf("Expected code %s, but was %s (%v)", binary.Generate(key20, 0), b.String(), err)
+		t.Fail()
+	}
+}
+
+func TestEpochTime(t *testing.T) {
+	key20 := []byte("12345678901234567890")
+	epoch := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	totp := NewTotp(WithEpochTime(epoch))
+	if !totp.Equal(NewTotp(WithEpoch(Counter(epoch.Unix())))) {
+		t.Logf("Expected the same epoch as %d, but was %d", epoch.Unix(), totp.Epoch())
+		t.Fail()
+	}
+	if c := totp.At(epoch.Add(59 * time.Second)); c != 1 {
+		t.Logf("Expected %d, but was %d", 1, c)
+		t.Fail()
+	}
+	negative := NewTotp(WithEpochTime(time.Unix(-60, 0)))
+	if c := negative.At(time.Unix(-1, 0)); c != 1 {
+		t.Logf("Expected %d, but was %d", 1, c)
+		t.Fail()
+	}
+	if c := negative.At(time.Unix(0, 0)); c != 2 {
+		t.Logf("Expected %d, but was %d", 2, c)
+		t.Fail()
+	}
+	if c := negative.At(time.Unix(-61, 0)); c != 0 {
+		t.Logf("Expected %d before the epoch, but was %d", 0, c)
+		t.Fail()
+	}
+	if code := negative.Code(key20, time.Unix(-31, 0)); code != "755224" {
+		t.Logf("Expected code %s, but was %s", "755224", code)
+		t.Fail()
+	}
+	if !negative.ValidateAt(key20, "287082", time.Unix(-1, 0)) {
+		t.Logf("Code %s expected to be valid", "287082")
+		t.Fail()
+	}
+	if remaining := negative.RemainingTime(time.Unix(-40, 0)); remaining != 10*time.Second {
+		t.Logf("Expected %v, but was %v", 10*time.Second, remaining)
+		t.Fail()
+	}
+}
+
+func TestCodeLength(t *testing.T) {
+	key20 := []byte("12345678901234567890")
+	hotp := NewHotp(WithDigits(8))
+	if hotp.Validate(key20, "755224", 0) {
+		t.Logf("Code %s expected to be rejected for its length", "755224")
+		t.Fail()
+	}
+	if _, err := hotp.ValidateE(key20, "755224", 0); !errors.Is(err, ErrCodeLength) {
+		t.Logf("Expected %v, but was %v", ErrCodeLength, err)
+		t.Fail()
+	}
+	lenient := NewHotp(WithDigits(8), WithLenientInput(true), WithChecksum(true))
+	code := FormatCode(lenient.Generate(key20, 0), 3, " ")
+	if !lenient.Validate(key20, code, 0) {
+		t.Logf("Grouped code %s with checksum digit expected to be valid", code)
+		t.Fail()
+	}
+}
+
+func TestModulus(t *testing.T) {
+	key20 := []byte("12345678901234567890")
+	testCases := []struct {
+		hotp  *hotp
+		code  string
+		value int64
+	}{
+		{hotp: NewHotp(WithModulus(1000)), code: "000224", value: 224},
+		{hotp: NewHotp(WithAlphabet("0123456789ABCDEF"), WithDigits(4), WithModulus(10000)), code: "1468", value: 5224},
+		{hotp: NewHotp(WithModulus(0)), code: "755224", value: 755224},
+	}
+	for _, tC := range testCases {
+		t.Run("Custom modulus", func(t *testing.T) {
+			if code := tC.hotp.Generate(key20, 0); code != tC.code {
+				t.Logf("Expected code %s, but was %s", tC.code, code)
+				t.Fail()
+			}
+			if value := tC.hotp.GenerateInt(key20, 0); value != tC.value {
+				t.Logf("Expected %d, but was %d", tC.value, value)
+				t.Fail()
+			}
+			if !tC.hotp.Validate(key20, tC.code, 0) {
+				t.Logf("Code %s expected to be valid", tC.code)
+				t.Fail()
+			}
+		})
+	}
+	for _, m := range []int{1, -5, 1000001} {
+		if _, err := NewHotpE(WithModulus(m)); !errors.Is(err, ErrModulus) {
+			t.Logf("Expected %v for %d, but was %v", ErrModulus, m, err)
+			t.Fail()
+		}
+		if code := NewHotp(WithModulus(m)).Generate(key20, 0); code != "755224" {
+			t.Logf("Expected the default modulus for %d, but was code %s", m, code)
+			t.Fail()
+		}
+	}
+}
+
+func TestCodeSpace(t *testing.T) {
+	testCases := []struct {
+		hotp     *hotp
+		expected int64
+	}{
+		{hotp: NewHotp(), expected: 1000000},
+		{hotp: NewHotp(WithDigits(9)), expected: 1000000000},
+		{hotp: NewHotp(WithChecksum(true)), expected: 1000000},
+		{hotp: NewHotp(steamGuard()), expected: 26 * 26 * 26 * 26 * 26},
+		{hotp: NewHotp(WithAlphabet("01"), WithDigits(31)), expected: 1 << 31},
+		{hotp: NewHotp(WithModulus(1000)), expected: 1000},
+	}
+	for _, tC := range testCases {
+		if space := tC.hotp.CodeSpace(); space != tC.expected {
+			t.Logf("Expected %d for %v, but was %d", tC.expected, tC.hotp, space)
+			t.Fail()
+		}
+	}
+	if space := NewSteamTotp().CodeSpace(); space != 26*26*26*26*26 {
+		t.Logf("Expected %d, but was %d", 26*26*26*26*26, space)
+		t.Fail()
+	}
+}
+
+// shortHash truncates the digests of the wrapped hash to size bytes.
+type shortHash struct {
+	hash.Hash
+	size int
+}
+
+func (h shortHash) Size() int {
+	return h.size
+}
+
+func (h shortHash) Sum(b []byte) []byte {
+	return h.Hash.Sum(b)[:len(b)+h.size]
+}
+
+func shortSHA1(size int) func() hash.Hash {
+	return func() hash.Hash { return shortHash{Hash: sha1.New(), size: size} }
+}
+
+func TestShortDigest(t *testing.T) {
+	key20 := []byte("12345678901234567890")
+	hotp := NewHotp(WithHash(shortSHA1(4)))
+	if _, err := hotp.GenerateE(key20, 0); !errors.Is(err, ErrDigestSize) {
+		t.Logf("Expected %v, but was %v", ErrDigestSize, err)
+		t.Fail()
+	}
+	if code := hotp.Generate(key20, 0); code != "" {
+		t.Logf("Expected empty code, but was %s", code)
+		t.Fail()
+	}
+	if _, err := hotp.GenerateInto(make([]byte, 6), key20, 0); !errors.Is(err, ErrDigestSize) {
+		t.Logf("Expected %v, but was %v", ErrDigestSize, err)
+		t.Fail()
+	}
+	if hotp.Validate(key20, "", 0) {
+		t.Log("Expected empty code to be rejected")
+		t.Fail()
+	}
+	if _, err := NewHotpE(WithHash(shortSHA1(16))); !errors.Is(err, ErrDigestSize) {
+		t.Logf("Expected %v, but was %v", ErrDigestSize, err)
+		t.Fail()
+	}
+	if _, err := NewHotpE(WithHash(shortSHA1(16)), WithTruncationOffset(12)); err != nil {
+		t.Logf("Expected no error, but was %v", err)
+		t.Fail()
+	}
+	code, err := NewHotp().GenerateE(key20, 0)
+	if err != nil || code != "755224" {
+		t.Logf("Expected %s, but was %s (%v)", "755224", code, err)
+		t.Fail()
+	}
+}
+
+func FuzzGenerate(f *testing.F) {
+	f.Add([]byte("12345678901234567890"), uint64(0), 20, -1)
+	f.Add([]byte{}, uint64(1<<63), 4, 0)
+	f.Add([]byte("key"), uint64(42), 1, 3)
+	f.Fuzz(func(t *testing.T, key []byte, counter uint64, size int, offset int) {
+		if size %= sha1.Size + 1; size < 0 {
+			size = -size
+		}
+		hotp := NewHotp(WithHash(shortSHA1(size)), WithTruncationOffset(offset))
+		code, err := hotp.GenerateE(key, Counter(counter))
+		if err != nil {
+			if !errors.Is(err, ErrDigestSize) {
+				t.Fatalf("Expected %v, but was %v", ErrDigestSize, err)
+			}
+			return
+		}
+		if !hotp.Validate(key, code, Counter(counter)) {
+			t.Fatalf("Expected %s to be valid", code)
+		}
+	})
+}
+
+func TestWideDigits(t *testing.T) {
+	key20 := []byte("12345678901234567890")
+	testCases := []struct {
+		counter Counter
+		nine    string
+		ten     string
+	}{
+		{counter: 0, nine: "284755224", ten: "1796610195"},
+		{counter: 1, nine: "094287082", ten: "1579434291"},
+		{counter: 2, nine: "137359152", ten: "7661791635"},
+		{counter: 3, nine: "726969429", ten: "0874249922"},
+		{counter: 4, nine: "640338314", ten: "5389580572"},
+		{counter: 5, nine: "868254676", ten: "2148968135"},
+		{counter: 6, nine: "918287922", ten: "1225853084"},
+		{counter: 7, nine: "082162583", ten: "0392106069"},
+		{counter: 8, nine: "673399871", ten: "3151854386"},
+		{counter: 9, nine: "645520489", ten: "1334437132"},
+	}
+	nine, ten := NewHotp(WithDigits(9)), NewHotp(WithDigits(10))
+	for _, tC := range testCases {
+		t.Run(fmt.Sprintf("Counter %d", tC.counter), func(t *testing.T) {
+			if code := nine.Generate(key20, tC.counter); code != tC.nine {
+				t.Logf("Expected %s, but was %s", tC.nine, code)
+				t.Fail()
+			}
+			if code := ten.Generate(key20, tC.counter); code != tC.ten {
+				t.Logf("Expected %s, but was %s", tC.ten, code)
+				t.Fail()
+			}
+			if !ten.Validate(key20, tC.ten, tC.counter) {
+				t.Logf("Code %s expected to be valid", tC.ten)
+				t.Fail()
+			}
+		})
+	}
+	if code := NewHotp(WithDigits(8)).Generate(key20, 0); code != "84755224" {
+		t.Logf("Expected RFC 4226 code %s, but was %s", "84755224", code)
+		t.Fail()
+	}
+	if space := ten.CodeSpace(); space != 10000000000 {
+		t.Logf("Expected %d, but was %d", int64(10000000000), space)
+		t.Fail()
+	}
+}
+
+func TestValidCodes(t *testing.T) {
+	key20 := []byte("12345678901234567890")
+	totp := NewTotp(WithSkew(1))
+	codes := totp.ValidCodes(key20, time.Unix(59, 0), 1)
+	expected := []string{"755224", "287082", "359152"}
+	if strings.Join(codes, ",") != strings.Join(expected, ",") {
+		t.Logf("Expected %v, but was %v", expected, codes)
+		t.Fail()
+	}
+	for _, code := range codes {
+		if !totp.ValidateAt(key20, code, time.Unix(59, 0)) {
+			t.Logf("Code %s expected to be valid", code)
+			t.Fail()
+		}
+	}
+	if codes := totp.ValidCodes(key20, time.Unix(0, 0), 1); strings.Join(codes, ",") != "755224,287082" {
+		t.Logf("Expected codes from counter 0, but was %v", codes)
+		t.Fail()
+	}
+	if codes := totp.ValidCodes(key20, time.Unix(59, 0), -1); len(codes) != 1 || codes[0] != "287082" {
+		t.Logf("Expected the current code only, but was %v", codes)
+		t.Fail()
+	}
+	if codes := NewTotp(WithEpoch(100)).ValidCodes(key20, time.Unix(59, 0), 1); codes != nil {
+		t.Logf("Expected no codes before the epoch, but was %v", codes)
+		t.Fail()
+	}
+}
+
+func TestCodeHistory(t *testing.T) {
+	key20 := []byte("12345678901234567890")
+	testCases := []struct {
+		desc     string
+		totp     *totp
+		unix     time.Time
+		n        int
+		expected []string
+	}{
+		{desc: "last codes", totp: NewTotp(), unix: time.Unix(100, 0), n: 3, expected: []string{"287082", "359152", "969429"}},
+		{desc: "current code", totp: NewTotp(), unix: time.Unix(100, 0), n: 1, expected: []string{"969429"}},
+		{desc: "near the epoch", totp: NewTotp(), unix: time.Unix(59, 0), n: 5, expected: []string{"755224", "287082"}},
+		{desc: "no codes", totp: NewTotp(), unix: time.Unix(100, 0), n: 0},
+		{desc: "before the epoch", totp: NewTotp(WithEpoch(100)), unix: time.Unix(59, 0), n: 3},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			codes := tC.totp.CodeHistory(key20, tC.unix, tC.n)
+			if strings.Join(codes, ",") != strings.Join(tC.expected, ",") {
+				t.Logf("Expected %v, but was %v", tC.expected, codes)
+				t.Fail()
+			}
+		})
+	}
+}
+
+func TestStrictMode(t *testing.T) {
+	key20 := []byte("12345678901234567890")
+	key64 := []byte("1234567890123456789012345678901234567890123456789012345678901234")
+	strict := NewTotp(WithHash(sha512.New), WithStrictMode(true))
+	if code := strict.Code(key20, time.Unix(59, 0)); code != "" {
+		t.Logf("Expected no code for a short key, but was %s", code)
+		t.Fail()
+	}
+	if _, err := strict.hotp.GenerateE(key20, 1); !errors.Is(err, ErrKeyLength) {
+		t.Logf("Expected %v, but was %v", ErrKeyLength, err)
+		t.Fail()
+	}
+	code := NewTotp(WithHash(sha512.New)).Code(key20, time.Unix(59, 0))
+	if strict.ValidateAt(key20, code, time.Unix(59, 0)) {
+		t.Logf("Code %s expected to be rejected for a short key", code)
+		t.Fail()
+	}
+	if _, err := strict.ValidateAtE(key20, code, time.Unix(59, 0)); !errors.Is(err, ErrKeyLength) {
+		t.Logf("Expected %v, but was %v", ErrKeyLength, err)
+		t.Fail()
+	}
+	if _, err := strict.hotp.ValidateE(key20, code, 1); !errors.Is(err, ErrKeyLength) {
+		t.Logf("Expected %v, but was %v", ErrKeyLength, err)
+		t.Fail()
+	}
+	if _, _, err := strict.hotp.ValidateLookAheadE(key20, code, 0, 1); !errors.Is(err, ErrKeyLength) {
+		t.Logf("Expected %v, but was %v", ErrKeyLength, err)
+		t.Fail()
+	}
+	if code := strict.Code(key64, time.Unix(59, 0)); code != "693936" {
+		t.Logf("Expected %s, but was %s", "693936", code)
+		t.Fail()
+	}
+	if !strict.ValidateAt(key64, "693936", time.Unix(59, 0)) {
+		t.Log("Expected the code to be valid with a 64 byte key")
+		t.Fail()
+	}
+	if NewTotp(WithStrictMode(true)).Equal(NewTotp()) {
+		t.Log("Expected strict and default instances to differ")
+		t.Fail()
+	}
+}
+
+func TestDigest(t *testing.T) {
+	key20 := []byte("12345678901234567890")
+	testCases := []struct {
+		counter Counter
+		digest  string
+	}{
+		{counter: 0, digest: "cc93cf18508d94934c64b65d8ba7667fb7cde4b0"},
+		{counter: 1, digest: "75a48a19d4cbe100644e8ac1397eea747a2d33ab"},
+		{counter: 9, digest: "1637409809a679dc698207310c8c7fc07290d9e5"},
+	}
+	hotp := NewHotp()
+	for _, tC := range testCases {
+		t.Run(fmt.Sprintf("Counter %d", tC.counter), func(t *testing.T) {
+			digest := hotp.Digest(key20, tC.counter)
+			if hex.EncodeToString(digest) != tC.digest {
+				t.Logf("Expected %s, but was %x", tC.digest, digest)
+				t.Fail()
+			}
+			binary, err := truncate(digest, -1)
+			if err != nil || hotp.format(binary) != hotp.Generate(key20, tC.counter) {
+				t.Logf("Expected the truncated digest to match %s (%v)", hotp.Generate(key20, tC.counter), err)
+				t.Fail()
+			}
+		})
+	}
+	if size := len(NewHotp(WithHash(sha512.New)).Digest(key20, 0)); size != sha512.Size {
+		t.Logf("Expected %d bytes, but was %d", sha512.Size, size)
+		t.Fail()
+	}
+}
+
+func TestCounterEndian(t *testing.T) {
+	key20 := []byte("12345678901234567890")
+	little := NewHotp(WithCounterEndian(binary.LittleEndian))
+	if code := little.Generate(key20, 1); code != "160385" {
+		t.Logf("Expected %s, but was %s", "160385", code)
+		t.Fail()
+	}
+	if !little.Validate(key20, "160385", 1) || little.Validate(key20, "287082", 1) {
+		t.Log("Expected codes of the little-endian counter only to be valid")
+		t.Fail()
+	}
+	if code := NewHotp(WithCounterEndian(nil)).Generate(key20, 1); code != "287082" {
+		t.Logf("Expected %s, but was %s", "287082", code)
+		t.Fail()
+	}
+	if little.Equal(NewHotp()) || !NewHotp(WithCounterEndian(binary.BigEndian)).Equal(NewHotp()) {
+		t.Log("Expected instances to be equal by byte order")
+		t.Fail()
+	}
+}
+
+func TestExplain(t *testing.T) {
+	key20 := []byte("12345678901234567890")
+	testCases := []struct {
+		hotp   *hotp
+		offset int
+		binary int
+		code   string
+	}{
+		{hotp: NewHotp(), offset: 11, binary: 0x41397eea, code: "287082"},
+		{hotp: NewHotp(WithDigits(8)), offset: 11, binary: 0x41397eea, code: "94287082"},
+		{hotp: NewHotp(WithTruncationOffset(0)), offset: 0, binary: 0x75a48a19, code: NewHotp(WithTruncationOffset(0)).Generate(key20, 1)},
+		{hotp: NewHotp(WithHash(shortSHA1(0))), offset: -1, binary: 0, code: ""},
+	}
+	for _, tC := range testCases {
+		t.Run("Truncation internals", func(t *testing.T) {
+			offset, binary, code := tC.hotp.Explain(key20, 1)
+			if offset != tC.offset || binary != tC.binary || code != tC.code {
+				t.Logf("Expected (%d, %#x, %q), but was (%d, %#x, %q)", tC.offset, tC.binary, tC.code, offset, binary, code)
+				t.Fail()
+			}
+		})
+	}
+}
+
+func TestExtendedTruncation(t *testing.T) {
+	key20 := []byte("12345678901234567890")
+	key64 := []byte("1234567890123456789012345678901234567890123456789012345678901234")
+	extended := NewHotp(WithHash(sha512.New), WithDigits(8), WithExtendedTruncation(true))
+	testCases := []struct {
+		counter Counter
+		offset  int
+		code    string
+	}{
+		{counter: 0, offset: 14, code: "53550594"},
+		{counter: 1, offset: 40, code: "56884792"},
+		{counter: 4, offset: 44, code: "15012684"},
+	}
+	for _, tC := range testCases {
+		t.Run("Offset over the whole digest", func(t *testing.T) {
+			offset, _, code := extended.Explain(key64, tC.counter)
+			if offset != tC.offset || code != tC.code || extended.Generate(key64, tC.counter) != tC.code {
+				t.Logf("Expected (%d, %s), but was (%d, %s)", tC.offset, tC.code, offset, code)
+				t.Fail()
+			}
+		})
+	}
+	if code := NewHotp(WithHash(sha512.New), WithDigits(8)).Generate(key64, 1); code != "90693936" {
+		t.Logf("Expected the default truncation to stay %s, but was %s", "90693936", code)
+		t.Fail()
+	}
+	sha1 := NewHotp(WithExtendedTruncation(true))
+	for counter := Counter(0); counter < 10; counter++ {
+		if code := sha1.Generate(key20, counter); code != NewHotp().Generate(key20, counter) {
+			t.Logf("Expected SHA1 codes to stay the same, but was %s at %d", code, counter)
+			t.Fail()
+		}
+	}
+	if sha1.Equal(NewHotp()) {
+		t.Log("Expected extended truncation to be compared")
+		t.Fail()
+	}
+}
+
+func TestTimeOfCounter(t *testing.T) {
+	testCases := []struct {
+		totp *totp
+		unix time.Time
+	}{
+		{totp: NewTotp(), unix: time.Unix(1111111109, 0)},
+		{totp: NewTotp(WithTimeStep(10 * time.Second)), unix: time.Unix(59, 0)},
+		{totp: NewTotp(WithTimeStep(250 * time.Millisecond)), unix: time.Unix(1234567890, 600000000)},
+		{totp: NewTotp(WithEpoch(100)), unix: time.Unix(2000000000, 0)},
+	}
+	for _, tC := range testCases {
+		t.Run("Start of the time step", func(t *testing.T) {
+			c := tC.totp.At(tC.unix)
+			start := tC.totp.TimeOfCounter(c)
+			if start.After(tC.unix) || !tC.unix.Before(start.Add(tC.totp.TimeStep())) {
+				t.Logf("Expected %v in the time step starting at %v", tC.unix, start)
+				t.Fail()
+			}
+			for _, counter := range []Counter{0, 1, c, c + 1} {
+				if roundTrip := tC.totp.At(tC.totp.TimeOfCounter(counter)); roundTrip != counter {
+					t.Logf("Expected %d, but was %d", counter, roundTrip)
+					t.Fail()
+				}
+			}
+		})
+	}
+	if start := NewTotp().TimeOfCounter(37037036); !start.Equal(time.Unix(1111111080, 0)) {
+		t.Logf("Expected %v, but was %v", time.Unix(1111111080, 0), start)
+		t.Fail()
+	}
+}
+
+func TestExpected(t *testing.T) {
+	key20 := []byte("12345678901234567890")
+	totp := NewTotp(WithDigits(8))
+	at := time.Unix(1111111109, 0)
+	if code := totp.Expected(key20, at); code != "07081804" || !totp.ValidateAt(key20, code, at) {
+		t.Logf("Expected %s, but was %s", "07081804", code)
+		t.Fail()
+	}
 }
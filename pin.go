@@ -0,0 +1,48 @@
+package otp
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+)
+
+// HashPIN returns the SHA256 hash of the static PIN for storage, as expected
+// by ValidateWithPIN. PINs are short, so the hashes must be kept as secret as
+// the keys.
+func HashPIN(pin string) []byte {
+	sum := sha256.Sum256([]byte(pin))
+
+	return sum[:]
+}
+
+// ValidateWithPIN validates the input of a static PIN followed by an HOTP code,
+// as expected by hardware token VPN gateways. The trailing code of the
+// configured length is validated like ValidateLookAhead, and the leading PIN
+// is compared in constant time against pinHash returned by HashPIN. Both are
+// always checked, so timing does not reveal which one was wrong, and the
+// observer is notified once with the combined result. Returns whether both
+// matched and the matched counter value.
+//
+// With WithChecksum, the code must include the checksum digit: the input is
+// split at the code length with the digit, as PINs of any length make the
+// split ambiguous otherwise, unlike Validate which also accepts bare codes.
+// With WithLenientInput, spaces and dashes are removed from the whole input
+// before the split, so they can't be part of the PIN.
+func (hp *hotp) ValidateWithPIN(key []byte, pinHash []byte, input string, counter Counter, window int) (bool, Counter) {
+	input = hp.strip(input)
+	n := hp.codeLength()
+	if len(input) < n {
+		hp.observe(false, 0)
+		return false, 0
+	}
+	pin, code := input[:len(input)-n], input[len(input)-n:]
+	pinOK := subtle.ConstantTimeCompare(HashPIN(pin), pinHash) == 1
+	ok, matched, _ := hp.lookAhead(context.Background(), key, code, counter, window)
+	ok = ok && pinOK
+	hp.observe(ok, lookAheadOffset(ok, matched, counter))
+	if !ok {
+		return false, 0
+	}
+
+	return true, matched
+}
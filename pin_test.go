@@ -0,0 +1,75 @@
+package otp
+
+import "testing"
+
+func TestValidateWithPIN(t *testing.T) {
+	key20 := []byte("12345678901234567890")
+	pinHash := HashPIN("4321")
+	testCases := []struct {
+		input   string
+		valid   bool
+		matched Counter
+	}{
+		{input: "4321755224", valid: true, matched: 0},
+		{input: "4321287082", valid: true, matched: 1},
+		{input: "4321359152", valid: false},
+		{input: "1234755224", valid: false},
+		{input: "755224", valid: false},
+		{input: "432175522", valid: false},
+		{input: "43217552240", valid: false},
+		{input: "", valid: false},
+	}
+	hotp := NewHotp()
+	for _, tC := range testCases {
+		t.Run("PIN and code", func(t *testing.T) {
+			valid, matched := hotp.ValidateWithPIN(key20, pinHash, tC.input, 0, 1)
+			if valid != tC.valid || matched != tC.matched {
+				t.Logf("Expected (%t, %d) for %q, but was (%t, %d)", tC.valid, tC.matched, tC.input, valid, matched)
+				t.Fail()
+			}
+		})
+	}
+	if valid, _ := hotp.ValidateWithPIN(key20, HashPIN(""), "755224", 0, 0); !valid {
+		t.Log("Expected a code with an empty PIN to be valid")
+		t.Fail()
+	}
+	checksum := NewHotp(WithChecksum(true))
+	if valid, _ := checksum.ValidateWithPIN(key20, pinHash, "4321"+checksum.Generate(key20, 0), 0, 0); !valid {
+		t.Log("Expected the PIN to be split before the checksum code")
+		t.Fail()
+	}
+	lenient := NewHotp(WithLenientInput(true))
+	if valid, _ := lenient.ValidateWithPIN(key20, HashPIN("12"), "12 755 224", 0, 0); !valid {
+		t.Log("Expected the separators to be removed before the split")
+		t.Fail()
+	}
+	if valid, _ := hotp.ValidateWithPIN(key20, HashPIN("12"), "12 755 224", 0, 0); valid {
+		t.Log("Expected separators to be rejected without lenient input")
+		t.Fail()
+	}
+}
+
+func TestValidateWithPINObserver(t *testing.T) {
+	key20 := []byte("12345678901234567890")
+	o := &recordingObserver{}
+	hotp := NewHotp(WithObserver(o))
+	hotp.ValidateWithPIN(key20, HashPIN("4321"), "1234755224", 0, 1)
+	hotp.ValidateWithPIN(key20, HashPIN("4321"), "4321287082", 0, 1)
+	hotp.ValidateWithPIN(key20, HashPIN("4321"), "755", 0, 1)
+	expected := []validation{{result: false, offset: 0}, {result: true, offset: 1}, {result: false, offset: 0}}
+	if len(o.validations) != len(expected) {
+		t.Logf("Expected %v, but was %v", expected, o.validations)
+		t.FailNow()
+	}
+	for i := range expected {
+		if o.validations[i] != expected[i] {
+			t.Logf("Expected %v at %d, but was %v", expected[i], i, o.validations[i])
+			t.Fail()
+		}
+	}
+	checksum := NewHotp(WithChecksum(true))
+	if valid, _ := checksum.ValidateWithPIN(key20, HashPIN("4321"), "4321755224", 0, 0); valid {
+		t.Log("Expected a bare code without the checksum digit to be rejected after a PIN")
+		t.Fail()
+	}
+}
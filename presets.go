@@ -0,0 +1,63 @@
+package otp
+
+import (
+	"hash"
+	"time"
+)
+
+// steamAlphabet holds the symbols of Steam Guard codes.
+const steamAlphabet = "23456789BCDFGHJKMNPQRTVWXY"
+
+// NewSteamTotp creates a new TOTP instance compatible with the Steam Guard
+// mobile authenticator. Steam Guard codes are 5 characters long and drawn from
+// the alphabet "23456789BCDFGHJKMNPQRTVWXY" instead of decimal digits. The
+// options are applied on top of the Steam Guard defaults.
+func NewSteamTotp(opts ...TotpOption) *totp {
+	return NewTotp(append([]TotpOption{steamGuard()}, opts...)...)
+}
+
+// NewAuthyTotp creates a new TOTP instance compatible with Authy: 7 digit
+// codes with a 10 second time step, using SHA1 like the RFC 6238 defaults.
+// The options are applied on top of the Authy defaults.
+func NewAuthyTotp(opts ...TotpOption) *totp {
+	return NewTotp(append([]TotpOption{authy()}, opts...)...)
+}
+
+// WithRFC6238Defaults configures the 8 digit codes with a 30 second time step
+// and the Unix epoch of the RFC 6238 appendix B test vectors. The hash
+// function is left as configured, as the test vectors cover SHA1, SHA256 and
+// SHA512 with keys of 20, 32 and 64 bytes respectively.
+func WithRFC6238Defaults() TotpOption {
+	return totpOption(func(tp *totp) {
+		tp.hotp.digits = 8
+		tp.timeStep = 30 * time.Second
+		tp.epoch = 0
+	})
+}
+
+// RecommendedDigits returns the code length to configure for the hash
+// function: 6 digits for SHA1, the RFC 4226 default supported by all
+// authenticator apps, and 8 digits for longer hashes, as in the RFC 6238 test
+// vectors, since apps supporting those hashes also support 8 digit codes.
+func RecommendedDigits(f func() hash.Hash) int {
+	if HashName(f) == "SHA1" {
+		return 6
+	}
+
+	return 8
+}
+
+func authy() TotpOption {
+	return totpOption(func(tp *totp) {
+		tp.hotp.digits = 7
+		tp.timeStep = 10 * time.Second
+	})
+}
+
+func steamGuard() HotpOption {
+	return hotpOption(func(hp *hotp) {
+		hp.alphabet = steamAlphabet
+		hp.digits = 5
+		hp.lsbFirst = true
+	})
+}
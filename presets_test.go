@@ -0,0 +1,113 @@
+package otp
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"hash"
+	"testing"
+	"time"
+)
+
+func TestSteamTotp(t *testing.T) {
+	key20 := []byte("12345678901234567890")
+	testCases := []struct {
+		unixTime time.Time
+		code     string
+	}{
+		{unixTime: time.Unix(59, 0), code: "PV9M4"},
+		{unixTime: time.Unix(1111111109, 0), code: "PY4YB"},
+		{unixTime: time.Unix(1234567890, 0), code: "VHHQY"},
+		{unixTime: time.Unix(2000000000, 0), code: "9N776"},
+	}
+	for _, tC := range testCases {
+		t.Run("Steam Guard codes", func(t *testing.T) {
+			totp := NewSteamTotp()
+			if code := totp.Code(key20, tC.unixTime); code != tC.code {
+				t.Logf("Expected code %s, but was %s", tC.code, code)
+				t.Fail()
+			}
+			if !totp.ValidateAt(key20, tC.code, tC.unixTime) {
+				t.Logf("Code %s expected to be valid", tC.code)
+				t.Fail()
+			}
+		})
+	}
+}
+
+func TestAuthyTotp(t *testing.T) {
+	key20 := []byte("12345678901234567890")
+	testCases := []struct {
+		unixTime time.Time
+		code     string
+	}{
+		{unixTime: time.Unix(59, 0), code: "8254676"},
+		{unixTime: time.Unix(1111111109, 0), code: "2476215"},
+		{unixTime: time.Unix(1234567890, 0), code: "1398914"},
+		{unixTime: time.Unix(2000000000, 0), code: "6231360"},
+	}
+	for _, tC := range testCases {
+		t.Run("Authy codes", func(t *testing.T) {
+			totp := NewAuthyTotp()
+			if code := totp.Code(key20, tC.unixTime); code != tC.code {
+				t.Logf("Expected code %s, but was %s", tC.code, code)
+				t.Fail()
+			}
+			if !totp.ValidateAt(key20, tC.code, tC.unixTime) {
+				t.Logf("Code %s expected to be valid", tC.code)
+				t.Fail()
+			}
+		})
+	}
+	if code := NewAuthyTotp(WithDigits(8)).Code(key20, time.Unix(59, 0)); code != "68254676" {
+		t.Logf("Expected options applied on top of the preset, but was %s", code)
+		t.Fail()
+	}
+}
+
+func TestRFC6238Defaults(t *testing.T) {
+	key20 := []byte("12345678901234567890")
+	key32 := []byte("12345678901234567890123456789012")
+	key64 := []byte("1234567890123456789012345678901234567890123456789012345678901234")
+	testCases := []struct {
+		hashFunc func() hash.Hash
+		key      []byte
+		unixTime time.Time
+		code     string
+	}{
+		{hashFunc: sha1.New, key: key20, unixTime: time.Unix(59, 0), code: "94287082"},
+		{hashFunc: sha256.New, key: key32, unixTime: time.Unix(59, 0), code: "46119246"},
+		{hashFunc: sha512.New, key: key64, unixTime: time.Unix(59, 0), code: "90693936"},
+		{hashFunc: sha1.New, key: key20, unixTime: time.Unix(20000000000, 0), code: "65353130"},
+		{hashFunc: sha256.New, key: key32, unixTime: time.Unix(20000000000, 0), code: "77737706"},
+		{hashFunc: sha512.New, key: key64, unixTime: time.Unix(20000000000, 0), code: "47863826"},
+	}
+	for _, tC := range testCases {
+		t.Run("RFC 6238 appendix B", func(t *testing.T) {
+			totp := NewTotp(WithEpoch(100), WithTimeStep(time.Minute), WithHash(tC.hashFunc), WithRFC6238Defaults())
+			if code := totp.Code(tC.key, tC.unixTime); code != tC.code {
+				t.Logf("Expected code %s, but was %s", tC.code, code)
+				t.Fail()
+			}
+		})
+	}
+}
+
+func TestRecommendedDigits(t *testing.T) {
+	testCases := []struct {
+		hashFunc func() hash.Hash
+		digits   int
+	}{
+		{hashFunc: sha1.New, digits: 6},
+		{hashFunc: sha256.New, digits: 8},
+		{hashFunc: sha512.New, digits: 8},
+		{hashFunc: md5.New, digits: 8},
+	}
+	for _, tC := range testCases {
+		if digits := RecommendedDigits(tC.hashFunc); digits != tC.digits {
+			t.Logf("Expected %d digits for %s, but was %d", tC.digits, HashName(tC.hashFunc), digits)
+			t.Fail()
+		}
+	}
+}
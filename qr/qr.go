@@ -0,0 +1,580 @@
+// Package qr renders otpauth:// provisioning URIs as QR codes for scanning
+// with authenticator apps. It implements the QR code model 2 encoder (ISO/IEC
+// 18004) in byte mode at the medium error correction level, which tolerates
+// about 15% of damaged modules.
+//
+// Example usage:
+//
+//	import (
+//		"github.com/sshilin/otp"
+//		"github.com/sshilin/otp/qr"
+//	)
+//
+//	totp := otp.NewTotp()
+//	png, err := qr.PNG(totp.URI("Example", "alice@example.com", key))
+package qr
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+)
+
+const (
+	// moduleSize is the size of a single module in pixels.
+	moduleSize = 8
+	// quietZone is the width of the blank margin around a code in modules.
+	quietZone = 4
+)
+
+// ErrTooLong is returned for content that doesn't fit into the largest QR code.
+var ErrTooLong = errors.New("qr: content is too long")
+
+// eccCodewordsPerBlock is the number of error correction codewords per block
+// at the medium level for each version.
+var eccCodewordsPerBlock = [41]int{
+	-1, 10, 16, 26, 18, 24, 16, 18, 22, 22, 26, 30, 22, 22, 24, 24, 28, 28, 26, 26,
+	26, 26, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28,
+}
+
+// numBlocks is the number of error correction blocks at the medium level for
+// each version.
+var numBlocks = [41]int{
+	-1, 1, 1, 1, 2, 2, 4, 4, 4, 5, 5, 5, 8, 9, 9, 10, 10, 11, 13, 14,
+	16, 17, 17, 18, 20, 21, 23, 25, 26, 28, 29, 31, 33, 35, 37, 38, 40, 43, 45, 47, 49,
+}
+
+// code is a square matrix of modules, true modules are dark.
+type code struct {
+	version    int
+	size       int
+	modules    [][]bool
+	isFunction [][]bool
+}
+
+// PNG encodes the content as a QR code and returns it as a PNG image.
+func PNG(content string) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := Write(&buf, content); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Write encodes the content as a QR code and writes it to w as a PNG image.
+func Write(w io.Writer, content string) error {
+	img, err := Image(content)
+	if err != nil {
+		return err
+	}
+
+	return png.Encode(w, img)
+}
+
+// Image encodes the content as a QR code and returns it as a grayscale image
+// with black modules on white, including the quiet zone, e.g. to draw a logo
+// over the center or to embed it into a larger image before encoding. The
+// error correction tolerates covering up to about 15% of the modules.
+func Image(content string) (*image.Gray, error) {
+	c, err := encode([]byte(content))
+	if err != nil {
+		return nil, err
+	}
+
+	return c.image(), nil
+}
+
+func encode(data []byte) (*code, error) {
+	version := 0
+	for v := 1; v <= 40; v++ {
+		if 4+charCountBits(v)+len(data)*8 <= dataCodewords(v)*8 {
+			version = v
+			break
+		}
+	}
+	if version == 0 {
+		return nil, ErrTooLong
+	}
+	c := newCode(version)
+	c.drawFunctionPatterns()
+	c.drawCodewords(addECCAndInterleave(version, dataBits(version, data)))
+	c.applyBestMask()
+
+	return c, nil
+}
+
+func newCode(version int) *code {
+	size := version*4 + 17
+	c := &code{
+		version:    version,
+		size:       size,
+		modules:    make([][]bool, size),
+		isFunction: make([][]bool, size),
+	}
+	for i := range c.modules {
+		c.modules[i] = make([]bool, size)
+		c.isFunction[i] = make([]bool, size)
+	}
+
+	return c
+}
+
+// charCountBits returns the length of the character count indicator for the
+// byte mode.
+func charCountBits(version int) int {
+	if version <= 9 {
+		return 8
+	}
+
+	return 16
+}
+
+// rawDataModules returns the number of modules left for data and error
+// correction after the function patterns are drawn.
+func rawDataModules(version int) int {
+	result := (16*version+128)*version + 64
+	if version >= 2 {
+		numAlign := version/7 + 2
+		result -= (25*numAlign-10)*numAlign - 55
+		if version >= 7 {
+			result -= 36
+		}
+	}
+
+	return result
+}
+
+func dataCodewords(version int) int {
+	return rawDataModules(version)/8 - eccCodewordsPerBlock[version]*numBlocks[version]
+}
+
+// dataBits encodes the data in byte mode and pads it to the data capacity.
+func dataBits(version int, data []byte) []byte {
+	var bb bitBuffer
+	bb.append(0x4, 4)
+	bb.append(len(data), charCountBits(version))
+	for _, b := range data {
+		bb.append(int(b), 8)
+	}
+	capacity := dataCodewords(version) * 8
+	terminator := capacity - len(bb)
+	if terminator > 4 {
+		terminator = 4
+	}
+	bb.append(0, terminator)
+	bb.append(0, (8-len(bb)%8)%8)
+	for pad := 0xEC; len(bb) < capacity; pad ^= 0xEC ^ 0x11 {
+		bb.append(pad, 8)
+	}
+
+	return bb.bytes()
+}
+
+// addECCAndInterleave splits the data into blocks, appends the Reed-Solomon
+// error correction codewords to each block, and interleaves the blocks.
+func addECCAndInterleave(version int, data []byte) []byte {
+	blocks := numBlocks[version]
+	eccLen := eccCodewordsPerBlock[version]
+	rawCodewords := rawDataModules(version) / 8
+	numShortBlocks := blocks - rawCodewords%blocks
+	shortBlockLen := rawCodewords / blocks
+
+	divisor := reedSolomonDivisor(eccLen)
+	result := make([][]byte, blocks)
+	for i, k := 0, 0; i < blocks; i++ {
+		n := shortBlockLen - eccLen
+		if i >= numShortBlocks {
+			n++
+		}
+		block := append([]byte(nil), data[k:k+n]...)
+		k += n
+		ecc := reedSolomonRemainder(block, divisor)
+		if i < numShortBlocks {
+			block = append(block, 0)
+		}
+		result[i] = append(block, ecc...)
+	}
+
+	interleaved := make([]byte, 0, rawCodewords)
+	for i := range result[0] {
+		for j, block := range result {
+			if i != shortBlockLen-eccLen || j >= numShortBlocks {
+				interleaved = append(interleaved, block[i])
+			}
+		}
+	}
+
+	return interleaved
+}
+
+func (c *code) set(x, y int, dark bool) {
+	c.modules[y][x] = dark
+	c.isFunction[y][x] = true
+}
+
+func (c *code) drawFunctionPatterns() {
+	for i := 0; i < c.size; i++ {
+		c.set(6, i, i%2 == 0)
+		c.set(i, 6, i%2 == 0)
+	}
+	c.drawFinderPattern(3, 3)
+	c.drawFinderPattern(c.size-4, 3)
+	c.drawFinderPattern(3, c.size-4)
+
+	positions := alignmentPatternPositions(c.version)
+	last := len(positions) - 1
+	for i, x := range positions {
+		for j, y := range positions {
+			if i == 0 && j == 0 || i == 0 && j == last || i == last && j == 0 {
+				continue
+			}
+			c.drawAlignmentPattern(x, y)
+		}
+	}
+	// Reserve the format area, the actual bits are drawn with the mask
+	c.drawFormatBits(0)
+	c.drawVersion()
+}
+
+func (c *code) drawFinderPattern(x, y int) {
+	for dy := -4; dy <= 4; dy++ {
+		for dx := -4; dx <= 4; dx++ {
+			xx, yy := x+dx, y+dy
+			if xx < 0 || xx >= c.size || yy < 0 || yy >= c.size {
+				continue
+			}
+			dist := max(abs(dx), abs(dy))
+			c.set(xx, yy, dist != 2 && dist != 4)
+		}
+	}
+}
+
+func (c *code) drawAlignmentPattern(x, y int) {
+	for dy := -2; dy <= 2; dy++ {
+		for dx := -2; dx <= 2; dx++ {
+			c.set(x+dx, y+dy, max(abs(dx), abs(dy)) != 1)
+		}
+	}
+}
+
+// drawFormatBits draws both copies of the error correction level and the mask
+// protected by the BCH(15, 5) code.
+func (c *code) drawFormatBits(mask int) {
+	bits := formatBits(mask)
+	for i := 0; i <= 5; i++ {
+		c.set(8, i, bit(bits, i))
+	}
+	c.set(8, 7, bit(bits, 6))
+	c.set(8, 8, bit(bits, 7))
+	c.set(7, 8, bit(bits, 8))
+	for i := 9; i < 15; i++ {
+		c.set(14-i, 8, bit(bits, i))
+	}
+	for i := 0; i < 8; i++ {
+		c.set(c.size-1-i, 8, bit(bits, i))
+	}
+	for i := 8; i < 15; i++ {
+		c.set(8, c.size-15+i, bit(bits, i))
+	}
+	c.set(8, c.size-8, true)
+}
+
+// formatBits returns the 15-bit format information for the medium error
+// correction level and the mask.
+func formatBits(mask int) int {
+	// The medium level is encoded as 00
+	data := mask
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = (rem << 1) ^ ((rem >> 9) * 0x537)
+	}
+
+	return (data<<10 | rem) ^ 0x5412
+}
+
+// drawVersion draws both copies of the version protected by the BCH(18, 6)
+// code, which is present in versions 7 and up.
+func (c *code) drawVersion() {
+	if c.version < 7 {
+		return
+	}
+	bits := versionBits(c.version)
+	for i := 0; i < 18; i++ {
+		a, b := c.size-11+i%3, i/3
+		c.set(a, b, bit(bits, i))
+		c.set(b, a, bit(bits, i))
+	}
+}
+
+func versionBits(version int) int {
+	rem := version
+	for i := 0; i < 12; i++ {
+		rem = (rem << 1) ^ ((rem >> 11) * 0x1F25)
+	}
+
+	return version<<12 | rem
+}
+
+func alignmentPatternPositions(version int) []int {
+	if version == 1 {
+		return nil
+	}
+	numAlign := version/7 + 2
+	step := (version*8 + numAlign*3 + 5) / (numAlign*4 - 4) * 2
+	result := make([]int, numAlign)
+	result[0] = 6
+	for i, pos := numAlign-1, version*4+10; i > 0; i, pos = i-1, pos-step {
+		result[i] = pos
+	}
+
+	return result
+}
+
+// drawCodewords places the codewords in the zigzag order: two module wide
+// columns from right to left, alternating upward and downward, skipping the
+// vertical timing pattern and the function modules.
+func (c *code) drawCodewords(data []byte) {
+	i := 0
+	for right := c.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vert := 0; vert < c.size; vert++ {
+			for j := 0; j < 2; j++ {
+				x := right - j
+				y := vert
+				if (right+1)&2 == 0 {
+					y = c.size - 1 - vert
+				}
+				if !c.isFunction[y][x] && i < len(data)*8 {
+					c.modules[y][x] = bit(int(data[i>>3]), 7-i&7)
+					i++
+				}
+			}
+		}
+	}
+}
+
+// applyBestMask applies the mask pattern with the lowest penalty score.
+func (c *code) applyBestMask() {
+	best, bestPenalty := 0, -1
+	for mask := 0; mask < 8; mask++ {
+		c.applyMask(mask)
+		c.drawFormatBits(mask)
+		if penalty := c.penalty(); bestPenalty < 0 || penalty < bestPenalty {
+			best, bestPenalty = mask, penalty
+		}
+		c.applyMask(mask)
+	}
+	c.applyMask(best)
+	c.drawFormatBits(best)
+}
+
+// applyMask XORs the data modules with the mask pattern, so applying the same
+// mask twice restores the modules.
+func (c *code) applyMask(mask int) {
+	for y := 0; y < c.size; y++ {
+		for x := 0; x < c.size; x++ {
+			if !c.isFunction[y][x] && masked(mask, x, y) {
+				c.modules[y][x] = !c.modules[y][x]
+			}
+		}
+	}
+}
+
+func masked(mask, x, y int) bool {
+	switch mask {
+	case 0:
+		return (x+y)%2 == 0
+	case 1:
+		return y%2 == 0
+	case 2:
+		return x%3 == 0
+	case 3:
+		return (x+y)%3 == 0
+	case 4:
+		return (x/3+y/2)%2 == 0
+	case 5:
+		return x*y%2+x*y%3 == 0
+	case 6:
+		return (x*y%2+x*y%3)%2 == 0
+	default:
+		return ((x+y)%2+x*y%3)%2 == 0
+	}
+}
+
+// penalty scores the modules by the rules of ISO/IEC 18004 section 7.8.3:
+// long runs of the same color, 2x2 blocks of the same color, finder-like
+// patterns, and imbalance of dark and light modules.
+func (c *code) penalty() int {
+	result, dark := 0, 0
+	for i := 0; i < c.size; i++ {
+		row := make([]bool, c.size)
+		col := make([]bool, c.size)
+		for j := 0; j < c.size; j++ {
+			row[j], col[j] = c.modules[i][j], c.modules[j][i]
+			if row[j] {
+				dark++
+			}
+		}
+		result += linePenalty(row) + linePenalty(col)
+	}
+	for y := 0; y < c.size-1; y++ {
+		for x := 0; x < c.size-1; x++ {
+			m := c.modules[y][x]
+			if m == c.modules[y][x+1] && m == c.modules[y+1][x] && m == c.modules[y+1][x+1] {
+				result += 3
+			}
+		}
+	}
+	percent := dark * 100 / (c.size * c.size)
+	result += abs(percent-50) / 5 * 10
+
+	return result
+}
+
+var finderLike = [...][11]bool{
+	{true, false, true, true, true, false, true, false, false, false, false},
+	{false, false, false, false, true, false, true, true, true, false, true},
+}
+
+func linePenalty(line []bool) int {
+	result := 0
+	run := 1
+	for i := 1; i <= len(line); i++ {
+		if i < len(line) && line[i] == line[i-1] {
+			run++
+			continue
+		}
+		if run >= 5 {
+			result += run - 2
+		}
+		run = 1
+	}
+	for i := 0; i+11 <= len(line); i++ {
+		for _, pattern := range finderLike {
+			match := true
+			for j, m := range pattern {
+				if line[i+j] != m {
+					match = false
+					break
+				}
+			}
+			if match {
+				result += 40
+			}
+		}
+	}
+
+	return result
+}
+
+func (c *code) image() *image.Gray {
+	side := (c.size + 2*quietZone) * moduleSize
+	img := image.NewGray(image.Rect(0, 0, side, side))
+	for i := range img.Pix {
+		img.Pix[i] = 0xff
+	}
+	for y := 0; y < c.size; y++ {
+		for x := 0; x < c.size; x++ {
+			if !c.modules[y][x] {
+				continue
+			}
+			for dy := 0; dy < moduleSize; dy++ {
+				for dx := 0; dx < moduleSize; dx++ {
+					img.SetGray((x+quietZone)*moduleSize+dx, (y+quietZone)*moduleSize+dy, color.Gray{})
+				}
+			}
+		}
+	}
+
+	return img
+}
+
+// reedSolomonDivisor returns the coefficients of the Reed-Solomon generator
+// polynomial of the given degree, excluding the leading term.
+func reedSolomonDivisor(degree int) []byte {
+	result := make([]byte, degree)
+	result[degree-1] = 1
+	root := byte(1)
+	for i := 0; i < degree; i++ {
+		for j := range result {
+			result[j] = gfMultiply(result[j], root)
+			if j+1 < len(result) {
+				result[j] ^= result[j+1]
+			}
+		}
+		root = gfMultiply(root, 0x02)
+	}
+
+	return result
+}
+
+// reedSolomonRemainder returns the error correction codewords for the data.
+func reedSolomonRemainder(data, divisor []byte) []byte {
+	result := make([]byte, len(divisor))
+	for _, b := range data {
+		factor := b ^ result[0]
+		copy(result, result[1:])
+		result[len(result)-1] = 0
+		for i, d := range divisor {
+			result[i] ^= gfMultiply(d, factor)
+		}
+	}
+
+	return result
+}
+
+// gfMultiply multiplies two elements of GF(2^8) modulo x^8+x^4+x^3+x^2+1.
+func gfMultiply(x, y byte) byte {
+	z := 0
+	for i := 7; i >= 0; i-- {
+		z = (z << 1) ^ ((z >> 7) * 0x11D)
+		z ^= int((y>>i)&1) * int(x)
+	}
+
+	return byte(z)
+}
+
+func bit(x, i int) bool {
+	return (x>>i)&1 != 0
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+
+	return x
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+
+	return b
+}
+
+type bitBuffer []bool
+
+func (bb *bitBuffer) append(val, n int) {
+	for i := n - 1; i >= 0; i-- {
+		*bb = append(*bb, bit(val, i))
+	}
+}
+
+func (bb bitBuffer) bytes() []byte {
+	result := make([]byte, len(bb)/8)
+	for i, b := range bb {
+		if b {
+			result[i>>3] |= 1 << (7 - i&7)
+		}
+	}
+
+	return result
+}
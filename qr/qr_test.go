@@ -0,0 +1,280 @@
+package qr
+
+import (
+	"bytes"
+	"errors"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+func TestReedSolomon(t *testing.T) {
+	// Codewords of "HELLO WORLD" in alphanumeric mode at 1-M
+	data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	expected := []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}
+	if ecc := reedSolomonRemainder(data, reedSolomonDivisor(10)); !bytes.Equal(ecc, expected) {
+		t.Logf("Expected %v, but was %v", expected, ecc)
+		t.Fail()
+	}
+}
+
+func TestFormatBits(t *testing.T) {
+	expected := []int{
+		0b101010000010010, 0b101000100100101, 0b101111001111100, 0b101101101001011,
+		0b100010111111001, 0b100000011001110, 0b100111110010111, 0b100101010100000,
+	}
+	for mask, bits := range expected {
+		if formatBits(mask) != bits {
+			t.Logf("Expected %015b for mask %d, but was %015b", bits, mask, formatBits(mask))
+			t.Fail()
+		}
+	}
+}
+
+func TestVersionBits(t *testing.T) {
+	expected := map[int]int{7: 0x07C94, 8: 0x085BC, 9: 0x09A99, 10: 0x0A4D3, 40: 0x28C69}
+	for version, bits := range expected {
+		if versionBits(version) != bits {
+			t.Logf("Expected %018b for version %d, but was %018b", bits, version, versionBits(version))
+			t.Fail()
+		}
+	}
+}
+
+func TestAlignmentPatternPositions(t *testing.T) {
+	testCases := []struct {
+		version   int
+		positions []int
+	}{
+		{version: 1, positions: nil},
+		{version: 2, positions: []int{6, 18}},
+		{version: 7, positions: []int{6, 22, 38}},
+		{version: 32, positions: []int{6, 34, 60, 86, 112, 138}},
+		{version: 40, positions: []int{6, 30, 58, 86, 114, 142, 170}},
+	}
+	for _, tC := range testCases {
+		t.Run("Alignment pattern positions", func(t *testing.T) {
+			positions := alignmentPatternPositions(tC.version)
+			if len(positions) != len(tC.positions) {
+				t.Logf("Expected %v, but was %v", tC.positions, positions)
+				t.FailNow()
+			}
+			for i := range positions {
+				if positions[i] != tC.positions[i] {
+					t.Logf("Expected %v, but was %v", tC.positions, positions)
+					t.Fail()
+				}
+			}
+		})
+	}
+}
+
+func TestCapacity(t *testing.T) {
+	testCases := []struct {
+		length  int
+		version int
+	}{
+		{length: 14, version: 1},
+		{length: 15, version: 2},
+		{length: 26, version: 2},
+		{length: 62, version: 4},
+		{length: 106, version: 6},
+		{length: 122, version: 7},
+		{length: 213, version: 10},
+		{length: 2331, version: 40},
+	}
+	for _, tC := range testCases {
+		t.Run("Byte mode capacity at medium level", func(t *testing.T) {
+			c, err := encode(bytes.Repeat([]byte("a"), tC.length))
+			if err != nil || c.version != tC.version {
+				t.Logf("Expected version %d for %d bytes, but was %+v (%v)", tC.version, tC.length, c, err)
+				t.Fail()
+			}
+		})
+	}
+	if _, err := encode(bytes.Repeat([]byte("a"), 2332)); !errors.Is(err, ErrTooLong) {
+		t.Logf("Expected %v, but was %v", ErrTooLong, err)
+		t.Fail()
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, content := range []string{
+		"",
+		"otpauth://totp/Example:alice@google.com?secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ&issuer=Example&algorithm=SHA1&digits=6&period=30",
+		strings.Repeat("otpauth://hotp/", 20),
+		strings.Repeat("x", 1000),
+	} {
+		c, err := encode([]byte(content))
+		if err != nil {
+			t.Logf("Expected no error, but was %v", err)
+			t.FailNow()
+		}
+		if decoded := decode(t, c.modules); decoded != content {
+			t.Logf("Expected %q, but was %q", content, decoded)
+			t.Fail()
+		}
+	}
+}
+
+func TestPNG(t *testing.T) {
+	content := "otpauth://totp/Example:alice?secret=GEZDGNBVGY3TQOJQ"
+	data, err := PNG(content)
+	if err != nil {
+		t.Logf("Expected no error, but was %v", err)
+		t.FailNow()
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Logf("Expected PNG, but was %v", err)
+		t.FailNow()
+	}
+	c, _ := encode([]byte(content))
+	if side := (c.size + 2*quietZone) * moduleSize; img.Bounds().Dx() != side || img.Bounds().Dy() != side {
+		t.Logf("Expected %dx%d image, but was %v", side, side, img.Bounds())
+		t.Fail()
+	}
+	modules := make([][]bool, c.size)
+	for y := range modules {
+		modules[y] = make([]bool, c.size)
+		for x := range modules[y] {
+			r, _, _, _ := img.At((x+quietZone)*moduleSize+moduleSize/2, (y+quietZone)*moduleSize+moduleSize/2).RGBA()
+			modules[y][x] = r == 0
+		}
+	}
+	if decoded := decode(t, modules); decoded != content {
+		t.Logf("Expected %q, but was %q", content, decoded)
+		t.Fail()
+	}
+}
+
+// decode reads back the content of a byte mode QR code at the medium level.
+func decode(t *testing.T, modules [][]bool) string {
+	size := len(modules)
+	version := (size - 17) / 4
+	c := newCode(version)
+	c.drawFunctionPatterns()
+
+	raw := 0
+	for i := 0; i <= 5; i++ {
+		raw |= b2i(modules[i][8]) << i
+	}
+	raw |= b2i(modules[7][8])<<6 | b2i(modules[8][8])<<7 | b2i(modules[8][7])<<8
+	for i := 9; i < 15; i++ {
+		raw |= b2i(modules[8][14-i]) << i
+	}
+	mask := (raw ^ 0x5412) >> 10
+	if mask > 7 || formatBits(mask) != raw {
+		t.Fatalf("Invalid format bits %015b", raw)
+	}
+
+	var bb bitBuffer
+	for right := size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vert := 0; vert < size; vert++ {
+			for j := 0; j < 2; j++ {
+				x, y := right-j, vert
+				if (right+1)&2 == 0 {
+					y = size - 1 - vert
+				}
+				if !c.isFunction[y][x] {
+					bb = append(bb, modules[y][x] != masked(mask, x, y))
+				}
+			}
+		}
+	}
+	codewords := bb[:len(bb)/8*8].bytes()
+
+	blocks := numBlocks[version]
+	eccLen := eccCodewordsPerBlock[version]
+	numShortBlocks := blocks - len(codewords)%blocks
+	shortDataLen := len(codewords)/blocks - eccLen
+	data := make([][]byte, blocks)
+	ecc := make([][]byte, blocks)
+	k := 0
+	for i := 0; i <= shortDataLen; i++ {
+		for j := range data {
+			if i < shortDataLen || j >= numShortBlocks {
+				data[j] = append(data[j], codewords[k])
+				k++
+			}
+		}
+	}
+	for i := 0; i < eccLen; i++ {
+		for j := range ecc {
+			ecc[j] = append(ecc[j], codewords[k])
+			k++
+		}
+	}
+	var payload []byte
+	for j := range data {
+		if !bytes.Equal(reedSolomonRemainder(data[j], reedSolomonDivisor(eccLen)), ecc[j]) {
+			t.Fatalf("Invalid error correction codewords in block %d", j)
+		}
+		payload = append(payload, data[j]...)
+	}
+
+	if payload[0]>>4 != 0x4 {
+		t.Fatalf("Expected byte mode, but was %04b", payload[0]>>4)
+	}
+	if charCountBits(version) == 8 {
+		n := int(payload[0]&0xf)<<4 | int(payload[1]>>4)
+		return string(shift(payload[1 : 1+n+1]))[:n]
+	}
+	n := int(payload[0]&0xf)<<12 | int(payload[1])<<4 | int(payload[2]>>4)
+	return string(shift(payload[2 : 2+n+1]))[:n]
+}
+
+// shift drops the leading 4 bits of the data.
+func shift(data []byte) []byte {
+	result := make([]byte, len(data))
+	for i := range data {
+		result[i] = data[i] << 4
+		if i+1 < len(data) {
+			result[i] |= data[i+1] >> 4
+		}
+	}
+
+	return result
+}
+
+func b2i(b bool) int {
+	if b {
+		return 1
+	}
+
+	return 0
+}
+
+func TestImage(t *testing.T) {
+	content := "otpauth://totp/Example:alice?secret=GEZDGNBVGY3TQOJQ"
+	img, err := Image(content)
+	if err != nil {
+		t.Logf("Expected no error, but was %v", err)
+		t.FailNow()
+	}
+	data, _ := PNG(content)
+	decoded, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Logf("Expected PNG, but was %v", err)
+		t.FailNow()
+	}
+	if img.Bounds() != decoded.Bounds() {
+		t.Logf("Expected %v, but was %v", decoded.Bounds(), img.Bounds())
+		t.FailNow()
+	}
+	for y := 0; y < img.Bounds().Dy(); y++ {
+		for x := 0; x < img.Bounds().Dx(); x++ {
+			if r, _, _, _ := decoded.At(x, y).RGBA(); uint8(r>>8) != img.GrayAt(x, y).Y {
+				t.Logf("Expected the pixel at %d,%d to match the PNG", x, y)
+				t.FailNow()
+			}
+		}
+	}
+	if _, err := Image(strings.Repeat("x", 3000)); !errors.Is(err, ErrTooLong) {
+		t.Logf("Expected %v, but was %v", ErrTooLong, err)
+		t.Fail()
+	}
+}
@@ -0,0 +1,95 @@
+package otp
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// ReplayStore records the TOTP codes accepted for each key id, so a code can't
+// be accepted twice within its validity window.
+type ReplayStore interface {
+	// Use marks the counter as used for the id at the validation time t and
+	// reports whether it was unused before. The record is needed only until the
+	// expiry time, when the code falls out of the validation window. Records
+	// are to expire relative to t rather than the wall clock, so historical
+	// times and injected clocks are guarded too.
+	Use(id string, counter Counter, t, expires time.Time) (bool, error)
+}
+
+// WithReplayGuard configures the store recording accepted codes for
+// ValidateOnce. Default: no replay protection.
+func WithReplayGuard(store ReplayStore) TotpOption {
+	return totpOption(func(tp *totp) {
+		tp.replay = store
+	})
+}
+
+// ValidateOnce validates a TOTP code like ValidateAt for the key identified by
+// id. With a replay guard configured, the matched time step is recorded in the
+// store and a code of the same step is rejected afterwards. With an attempt
+// limiter configured, denied attempts fail with ErrRateLimited. The observer is
+// notified once, with replayed codes reported as failures.
+func (tp *totp) ValidateOnce(id string, key []byte, code string, t time.Time) (bool, error) {
+	return tp.hotp.limit(id, func() (bool, error) {
+		ok, offset, _ := tp.scan(context.Background(), key, code, t)
+		if ok && tp.replay != nil {
+			counter := tp.At(t) + Counter(offset)
+			unused, err := tp.replay.Use(id, counter, t, tp.expires(counter))
+			if err != nil {
+				tp.hotp.observe(false, 0)
+				return false, err
+			}
+			ok = unused
+		}
+		if !ok {
+			offset = 0
+		}
+		tp.hotp.observe(ok, offset)
+
+		return ok, nil
+	})
+}
+
+// expires returns the time when the code of the counter falls out of the
+// validation window.
+func (tp *totp) expires(counter Counter) time.Time {
+	return tp.start(counter + Counter(tp.skew) + 1)
+}
+
+type replayKey struct {
+	id      string
+	counter Counter
+}
+
+// MemoryReplayStore is an in-memory ReplayStore for tests and small
+// deployments. Records expired at the validation time are purged on use. It's
+// safe for concurrent use.
+type MemoryReplayStore struct {
+	mu   sync.Mutex
+	used map[replayKey]time.Time
+}
+
+// NewMemoryReplayStore creates a new empty MemoryReplayStore.
+func NewMemoryReplayStore() *MemoryReplayStore {
+	return &MemoryReplayStore{used: make(map[replayKey]time.Time)}
+}
+
+// Use marks the counter as used for the id until the expiry time, purging the
+// records expired at t.
+func (s *MemoryReplayStore) Use(id string, counter Counter, t, expires time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for k, exp := range s.used {
+		if !exp.After(t) {
+			delete(s.used, k)
+		}
+	}
+	k := replayKey{id: id, counter: counter}
+	if _, ok := s.used[k]; ok {
+		return false, nil
+	}
+	s.used[k] = expires
+
+	return true, nil
+}
@@ -0,0 +1,94 @@
+package otp
+
+import (
+	"testing"
+	"time"
+)
+
+func TestValidateOnce(t *testing.T) {
+	key20 := []byte("12345678901234567890")
+	now := time.Now()
+	totp := NewTotp(WithSkew(1), WithReplayGuard(NewMemoryReplayStore()))
+	code := totp.Code(key20, now)
+	if ok, err := totp.ValidateOnce("alice", key20, code, now); err != nil || !ok {
+		t.Logf("Code %s expected to be valid (%v)", code, err)
+		t.Fail()
+	}
+	if ok, _ := totp.ValidateOnce("alice", key20, code, now); ok {
+		t.Logf("Code %s expected to be rejected on replay", code)
+		t.Fail()
+	}
+	if ok, _ := totp.ValidateOnce("alice", key20, code, now.Add(30*time.Second)); ok {
+		t.Logf("Code %s expected to be rejected on replay in the next step", code)
+		t.Fail()
+	}
+	if ok, _ := totp.ValidateOnce("bob", key20, code, now); !ok {
+		t.Logf("Code %s expected to be valid for another id", code)
+		t.Fail()
+	}
+	if ok, _ := totp.ValidateOnce("alice", key20, "000000", now); ok {
+		t.Logf("Code %s expected to be invalid", "000000")
+		t.Fail()
+	}
+}
+
+func TestValidateOnceObserver(t *testing.T) {
+	key20 := []byte("12345678901234567890")
+	o := &recordingObserver{}
+	totp := NewTotp(WithReplayGuard(NewMemoryReplayStore()), WithObserver(o))
+	now := time.Now()
+	code := totp.Code(key20, now)
+	totp.ValidateOnce("alice", key20, code, now)
+	totp.ValidateOnce("alice", key20, code, now)
+	expected := []validation{{result: true, offset: 0}, {result: false, offset: 0}}
+	if len(o.validations) != len(expected) || o.validations[0] != expected[0] || o.validations[1] != expected[1] {
+		t.Logf("Expected %v, but was %v", expected, o.validations)
+		t.Fail()
+	}
+}
+
+func TestValidateOnceWithoutGuard(t *testing.T) {
+	key20 := []byte("12345678901234567890")
+	now := time.Now()
+	totp := NewTotp()
+	code := totp.Code(key20, now)
+	for i := 0; i < 2; i++ {
+		if ok, err := totp.ValidateOnce("alice", key20, code, now); err != nil || !ok {
+			t.Logf("Code %s expected to be valid without replay guard (%v)", code, err)
+			t.Fail()
+		}
+	}
+}
+
+func TestReplayExpiry(t *testing.T) {
+	totp := NewTotp(WithSkew(1))
+	expires := totp.expires(totp.At(time.Unix(59, 0)))
+	if !expires.Equal(time.Unix(90, 0)) {
+		t.Logf("Expected %v, but was %v", time.Unix(90, 0), expires)
+		t.Fail()
+	}
+	store := NewMemoryReplayStore()
+	store.Use("alice", 1, time.Unix(59, 0), time.Unix(90, 0))
+	if ok, _ := store.Use("alice", 1, time.Unix(89, 0), time.Unix(120, 0)); ok {
+		t.Logf("Expected a record to be kept until its expiry")
+		t.Fail()
+	}
+	if ok, _ := store.Use("alice", 1, time.Unix(90, 0), time.Unix(120, 0)); !ok {
+		t.Logf("Expected an expired record to be purged")
+		t.Fail()
+	}
+}
+
+func TestValidateOnceFixedTime(t *testing.T) {
+	key20 := []byte("12345678901234567890")
+	totp := NewTotp(WithReplayGuard(NewMemoryReplayStore()), WithFixedTime(time.Unix(1700000000, 0)))
+	code := totp.Code(key20, totp.Now())
+	if ok, err := totp.ValidateOnce("alice", key20, code, totp.Now()); err != nil || !ok {
+		t.Logf("Code %s expected to be valid (%v)", code, err)
+		t.Fail()
+	}
+	if ok, _ := totp.ValidateOnce("alice", key20, code, totp.Now()); ok {
+		t.Logf("Code %s expected to be rejected on replay at a fixed time", code)
+		t.Fail()
+	}
+}
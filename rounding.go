@@ -0,0 +1,64 @@
+package otp
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrRounding is returned for an unknown rounding mode.
+var ErrRounding = errors.New("otp: unknown rounding mode")
+
+// Rounding is the mode of rounding the time to a TOTP counter value.
+type Rounding int
+
+const (
+	// RoundFloor counts the time steps started since the epoch, as specified
+	// by RFC 6238.
+	RoundFloor Rounding = iota
+	// RoundNearest rounds to the nearest time step boundary, halfway times
+	// rounding up.
+	RoundNearest
+	// RoundCeil counts the time steps started or completed since the epoch, so
+	// the counter advances right after every time step boundary.
+	RoundCeil
+)
+
+// String returns the name of the rounding mode, e.g. "RoundFloor".
+func (r Rounding) String() string {
+	switch r {
+	case RoundFloor:
+		return "RoundFloor"
+	case RoundNearest:
+		return "RoundNearest"
+	case RoundCeil:
+		return "RoundCeil"
+	}
+
+	return fmt.Sprintf("Rounding(%d)", int(r))
+}
+
+// WithRounding configures how At rounds the time to a counter value, for
+// interoperability with tokens that round to the nearest time step rather
+// than down. The time steps, as returned by RemainingTime, Window and
+// TimeOfCounter, shift along: with RoundNearest the counter 1 covers from half
+// a time step after the epoch up to one and a half. Only RoundFloor matches
+// RFC 6238 and authenticator apps. Default: RoundFloor.
+func WithRounding(mode Rounding) TotpOption {
+	return totpOption(func(tp *totp) {
+		tp.rounding = mode
+	})
+}
+
+// shift returns the offset added to times before rounding them down to a
+// counter value, which implements the other rounding modes.
+func (tp *totp) shift() time.Duration {
+	switch tp.rounding {
+	case RoundNearest:
+		return tp.timeStep / 2
+	case RoundCeil:
+		return tp.timeStep - time.Nanosecond
+	}
+
+	return 0
+}
@@ -0,0 +1,77 @@
+package otp
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRounding(t *testing.T) {
+	testCases := []struct {
+		desc    string
+		totp    *totp
+		unix    time.Time
+		counter Counter
+	}{
+		{desc: "floor at the epoch", totp: NewTotp(WithTimeStep(10 * time.Second)), unix: time.Unix(0, 0), counter: 0},
+		{desc: "floor at half step", totp: NewTotp(WithTimeStep(10 * time.Second)), unix: time.Unix(5, 0), counter: 0},
+		{desc: "floor before the boundary", totp: NewTotp(WithTimeStep(10 * time.Second)), unix: time.Unix(9, 999999999), counter: 0},
+		{desc: "floor at the boundary", totp: NewTotp(WithTimeStep(10 * time.Second)), unix: time.Unix(10, 0), counter: 1},
+		{desc: "nearest at the epoch", totp: NewTotp(WithTimeStep(10*time.Second), WithRounding(RoundNearest)), unix: time.Unix(0, 0), counter: 0},
+		{desc: "nearest before half step", totp: NewTotp(WithTimeStep(10*time.Second), WithRounding(RoundNearest)), unix: time.Unix(4, 999999999), counter: 0},
+		{desc: "nearest at half step", totp: NewTotp(WithTimeStep(10*time.Second), WithRounding(RoundNearest)), unix: time.Unix(5, 0), counter: 1},
+		{desc: "nearest at the boundary", totp: NewTotp(WithTimeStep(10*time.Second), WithRounding(RoundNearest)), unix: time.Unix(10, 0), counter: 1},
+		{desc: "nearest before next half step", totp: NewTotp(WithTimeStep(10*time.Second), WithRounding(RoundNearest)), unix: time.Unix(14, 0), counter: 1},
+		{desc: "nearest at next half step", totp: NewTotp(WithTimeStep(10*time.Second), WithRounding(RoundNearest)), unix: time.Unix(15, 0), counter: 2},
+		{desc: "ceil at the epoch", totp: NewTotp(WithTimeStep(10*time.Second), WithRounding(RoundCeil)), unix: time.Unix(0, 0), counter: 0},
+		{desc: "ceil after the epoch", totp: NewTotp(WithTimeStep(10*time.Second), WithRounding(RoundCeil)), unix: time.Unix(0, 1), counter: 1},
+		{desc: "ceil at the boundary", totp: NewTotp(WithTimeStep(10*time.Second), WithRounding(RoundCeil)), unix: time.Unix(10, 0), counter: 1},
+		{desc: "ceil after the boundary", totp: NewTotp(WithTimeStep(10*time.Second), WithRounding(RoundCeil)), unix: time.Unix(10, 1), counter: 2},
+		{desc: "nearest before the epoch", totp: NewTotp(WithEpoch(10), WithTimeStep(10*time.Second), WithRounding(RoundNearest)), unix: time.Unix(5, 0), counter: 0},
+		{desc: "nearest after the epoch", totp: NewTotp(WithEpoch(10), WithTimeStep(10*time.Second), WithRounding(RoundNearest)), unix: time.Unix(15, 0), counter: 1},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			if c := tC.totp.At(tC.unix); c != tC.counter {
+				t.Logf("Expected %d, but was %d", tC.counter, c)
+				t.Fail()
+			}
+			if c := tC.totp.At(tC.totp.ExpiresAt(tC.unix)); c != tC.counter+1 {
+				t.Logf("Expected counter %d at expiry, but was %d", tC.counter+1, c)
+				t.Fail()
+			}
+			if start := tC.totp.TimeOfCounter(tC.counter + 1); tC.totp.At(start) != tC.counter+1 || tC.totp.At(start.Add(-time.Nanosecond)) != tC.counter {
+				t.Logf("Expected counter %d to start at %v", tC.counter+1, start)
+				t.Fail()
+			}
+		})
+	}
+}
+
+func TestRoundingCodes(t *testing.T) {
+	key20 := []byte("12345678901234567890")
+	totp := NewTotp(WithRounding(RoundNearest))
+	if code := totp.Code(key20, time.Unix(45, 0)); code != "359152" {
+		t.Logf("Expected %s, but was %s", "359152", code)
+		t.Fail()
+	}
+	if !totp.ValidateAt(key20, "287082", time.Unix(15, 0)) || totp.Equal(NewTotp()) {
+		t.Logf("Code %s expected to be valid half a time step early", "287082")
+		t.Fail()
+	}
+}
+
+func TestRoundingRange(t *testing.T) {
+	if _, err := NewTotpE(WithRounding(Rounding(3))); !errors.Is(err, ErrRounding) {
+		t.Logf("Expected %v, but was %v", ErrRounding, err)
+		t.Fail()
+	}
+	if rounding := NewTotp(WithRounding(Rounding(-1))).rounding; rounding != RoundFloor {
+		t.Logf("Expected %v, but was %v", RoundFloor, rounding)
+		t.Fail()
+	}
+	if s := RoundNearest.String(); s != "RoundNearest" {
+		t.Logf("Expected %s, but was %s", "RoundNearest", s)
+		t.Fail()
+	}
+}
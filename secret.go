@@ -0,0 +1,141 @@
+package otp
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"strings"
+)
+
+const (
+	// DefaultSecretSize is the secret length in bytes recommended by RFC 4226
+	// for HMAC-SHA1.
+	DefaultSecretSize = 20
+	// minSecretSize is the shortest secret allowed by RFC 4226 (128 bits).
+	minSecretSize = 16
+)
+
+// ErrSecretSize is returned when generating a secret shorter than 16 bytes.
+var ErrSecretSize = errors.New("otp: secret must be at least 16 bytes")
+
+// ErrKeyLength is returned for keys shorter than recommended for the hash
+// function.
+var ErrKeyLength = errors.New("otp: key too short for the hash function")
+
+// Secret represents a shared secret key. Secret can be passed anywhere a raw
+// key is expected, e.g. to Generate and Validate, without copying the key.
+type Secret []byte
+
+// ParseBase32 decodes a Base32-encoded secret as used by provisioning URIs and
+// authenticator apps. Lowercase letters, whitespace and missing "=" padding are
+// accepted.
+func ParseBase32(s string) (Secret, error) {
+	s = strings.ToUpper(strings.Join(strings.Fields(s), ""))
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(strings.TrimRight(s, "="))
+	if err != nil {
+		return nil, fmt.Errorf("otp: invalid base32 secret: %w", err)
+	}
+
+	return key, nil
+}
+
+// ParseHexSecret decodes a hex-encoded secret as distributed for hardware
+// tokens. Uppercase letters and whitespace are accepted.
+func ParseHexSecret(s string) (Secret, error) {
+	key, err := hex.DecodeString(strings.Join(strings.Fields(s), ""))
+	if err != nil {
+		return nil, fmt.Errorf("otp: invalid hex secret: %w", err)
+	}
+
+	return key, nil
+}
+
+// ParseBase64Secret decodes a Base64-encoded secret. Both the standard and the
+// URL-safe alphabets are accepted, with or without "=" padding, as well as
+// whitespace. Base32 secrets are often valid Base64 too but decode to a
+// different key, so the encoding must be known rather than guessed.
+func ParseBase64Secret(s string) (Secret, error) {
+	s = strings.TrimRight(strings.Join(strings.Fields(s), ""), "=")
+	encoding := base64.RawStdEncoding
+	if strings.ContainsAny(s, "-_") {
+		encoding = base64.RawURLEncoding
+	}
+	key, err := encoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("otp: invalid base64 secret: %w", err)
+	}
+
+	return key, nil
+}
+
+// Bytes returns the raw secret key.
+func (s Secret) Bytes() []byte {
+	return s
+}
+
+// Base32 returns the secret encoded in Base32 without padding, the form
+// expected by authenticator apps.
+func (s Secret) Base32() string {
+	return base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(s)
+}
+
+// Wipe overwrites the secret key with zeros, so it doesn't linger in memory
+// after use. Wiping is best-effort: the garbage collector may have copied the
+// key while moving memory. The hash state derived from the key is wiped after
+// generation and validation with SHA1, SHA256 and SHA512, but custom hash
+// functions and Verifier release it without wiping.
+func (s Secret) Wipe() {
+	for i := range s {
+		s[i] = 0
+	}
+}
+
+// ValidateKeyLength checks that the key is at least as long as the output of
+// the hash function, as RFC 6238 recommends: 20 bytes for SHA1, 32 bytes for
+// SHA256 and 64 bytes for SHA512. Shorter keys still work, but weaken the
+// codes. Use it to catch misconfigured enrollments.
+func ValidateKeyLength(key []byte, f func() hash.Hash) error {
+	if min := f().Size(); len(key) < min {
+		return fmt.Errorf("%w, must be at least %d bytes, but was %d", ErrKeyLength, min, len(key))
+	}
+
+	return nil
+}
+
+// GenerateSecret generates a random secret of the given length in bytes using
+// crypto/rand. Use DefaultSecretSize unless the hash function requires a
+// longer key, e.g. 32 bytes for SHA256 and 64 bytes for SHA512.
+func GenerateSecret(bytes int) (Secret, error) {
+	return GenerateSecretFrom(rand.Reader, bytes)
+}
+
+// GenerateSecretFrom generates a secret like GenerateSecret, but reads the
+// random bytes from r, e.g. an HSM-backed source or a deterministic reader in
+// tests. Returns an error if r returns fewer bytes than requested.
+func GenerateSecretFrom(r io.Reader, bytes int) (Secret, error) {
+	if bytes < minSecretSize {
+		return nil, fmt.Errorf("%w, but was %d", ErrSecretSize, bytes)
+	}
+	key := make([]byte, bytes)
+	if _, err := io.ReadFull(r, key); err != nil {
+		return nil, fmt.Errorf("otp: failed to generate secret: %w", err)
+	}
+
+	return key, nil
+}
+
+// GenerateSecretBase32 generates a random secret like GenerateSecret and
+// returns it encoded in Base32 without padding.
+func GenerateSecretBase32(bytes int) (string, error) {
+	secret, err := GenerateSecret(bytes)
+	if err != nil {
+		return "", err
+	}
+
+	return secret.Base32(), nil
+}
@@ -0,0 +1,230 @@
+package otp
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"errors"
+	"hash"
+	"io"
+	"testing"
+)
+
+func TestParseBase32(t *testing.T) {
+	key20 := []byte("12345678901234567890")
+	testCases := []struct {
+		encoded string
+	}{
+		{encoded: "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"},
+		{encoded: "gezdgnbvgy3tqojqgezdgnbvgy3tqojq"},
+		{encoded: "GEZD GNBV GY3T QOJQ GEZD GNBV GY3T QOJQ"},
+	}
+	for _, tC := range testCases {
+		t.Run("Base32 secret", func(t *testing.T) {
+			secret, err := ParseBase32(tC.encoded)
+			if err != nil {
+				t.Logf("Expected no error, but was %v", err)
+				t.FailNow()
+			}
+			if !bytes.Equal(secret.Bytes(), key20) {
+				t.Logf("Expected secret %x, but was %x", key20, secret.Bytes())
+				t.Fail()
+			}
+			if secret.Base32() != "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ" {
+				t.Logf("Unexpected encoding %s", secret.Base32())
+				t.Fail()
+			}
+		})
+	}
+}
+
+func TestParseBase32Padding(t *testing.T) {
+	for _, encoded := range []string{"MFRGG===", "MFRGG", "mfrgg="} {
+		secret, err := ParseBase32(encoded)
+		if err != nil || string(secret) != "abc" {
+			t.Logf("Expected secret abc, but was %q (%v)", secret, err)
+			t.Fail()
+		}
+		if secret.Base32() != "MFRGG" {
+			t.Logf("Expected encoding MFRGG, but was %s", secret.Base32())
+			t.Fail()
+		}
+	}
+}
+
+func TestParseBase32Invalid(t *testing.T) {
+	for _, encoded := range []string{"MFRG1", "MF=RG", "MFRGG===A", "ÄÖ"} {
+		if _, err := ParseBase32(encoded); err == nil {
+			t.Logf("Expected error for %q", encoded)
+			t.Fail()
+		}
+	}
+}
+
+func TestParseHexSecret(t *testing.T) {
+	key20 := []byte("12345678901234567890")
+	for _, encoded := range []string{
+		"3132333435363738393031323334353637383930",
+		"3132 3334 3536 3738 3930\n3132 3334 3536 3738 3930",
+	} {
+		secret, err := ParseHexSecret(encoded)
+		if err != nil || !bytes.Equal(secret, key20) {
+			t.Logf("Expected secret %x, but was %x (%v)", key20, secret, err)
+			t.Fail()
+		}
+	}
+	if secret, err := ParseHexSecret("DEADbeef"); err != nil || !bytes.Equal(secret, []byte{0xde, 0xad, 0xbe, 0xef}) {
+		t.Logf("Expected secret deadbeef, but was %x (%v)", secret, err)
+		t.Fail()
+	}
+}
+
+func TestParseHexSecretInvalid(t *testing.T) {
+	for _, encoded := range []string{"313", "31zz", "0x31"} {
+		if _, err := ParseHexSecret(encoded); err == nil {
+			t.Logf("Expected error for %q", encoded)
+			t.Fail()
+		}
+	}
+}
+
+func TestParseBase64Secret(t *testing.T) {
+	key := []byte{0xfb, 0xff, 0xbf, 0x12, 0x34}
+	for _, encoded := range []string{"+/+/EjQ=", "+/+/EjQ", "-_-_EjQ=", "-_-_EjQ", " +/+/ EjQ=\n"} {
+		secret, err := ParseBase64Secret(encoded)
+		if err != nil || !bytes.Equal(secret, key) {
+			t.Logf("Expected secret %x for %q, but was %x (%v)", key, encoded, secret, err)
+			t.Fail()
+		}
+	}
+	key20 := []byte("12345678901234567890")
+	if secret, err := ParseBase64Secret("MTIzNDU2Nzg5MDEyMzQ1Njc4OTA="); err != nil || !bytes.Equal(secret, key20) {
+		t.Logf("Expected secret %x, but was %x (%v)", key20, secret, err)
+		t.Fail()
+	}
+}
+
+func TestParseBase64SecretInvalid(t *testing.T) {
+	for _, encoded := range []string{"+/-_EjQ=", "EjQ!", "A", "E=jQ"} {
+		if _, err := ParseBase64Secret(encoded); err == nil {
+			t.Logf("Expected error for %q", encoded)
+			t.Fail()
+		}
+	}
+}
+
+func TestSecretAsKey(t *testing.T) {
+	secret, _ := ParseBase32("GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ")
+	if code := NewHotp().Generate(secret, 0); code != "755224" {
+		t.Logf("Expected code %s, but was %s", "755224", code)
+		t.Fail()
+	}
+}
+
+func TestSecretWipe(t *testing.T) {
+	secret, _ := ParseBase32("GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ")
+	hotp := NewHotp()
+	if !hotp.Validate(secret, "755224", 0) {
+		t.Logf("Code %s expected to be valid", "755224")
+		t.Fail()
+	}
+	secret.Wipe()
+	for i, b := range secret {
+		if b != 0 {
+			t.Logf("Expected zero at %d, but was %d", i, b)
+			t.Fail()
+		}
+	}
+	if hotp.Validate(secret, "755224", 0) {
+		t.Logf("Code %s expected to be invalid after wipe", "755224")
+		t.Fail()
+	}
+}
+
+func TestGenerateSecret(t *testing.T) {
+	for _, size := range []int{16, DefaultSecretSize, 32, 64} {
+		secret, err := GenerateSecret(size)
+		if err != nil || len(secret) != size {
+			t.Logf("Expected %d bytes, but was %d (%v)", size, len(secret), err)
+			t.Fail()
+		}
+		other, _ := GenerateSecret(size)
+		if bytes.Equal(secret, other) {
+			t.Logf("Expected distinct secrets, but both were %x", secret)
+			t.Fail()
+		}
+	}
+	for _, size := range []int{-1, 0, 10, 15} {
+		if _, err := GenerateSecret(size); !errors.Is(err, ErrSecretSize) {
+			t.Logf("Expected %v, but was %v", ErrSecretSize, err)
+			t.Fail()
+		}
+		if _, err := GenerateSecretBase32(size); !errors.Is(err, ErrSecretSize) {
+			t.Logf("Expected %v, but was %v", ErrSecretSize, err)
+			t.Fail()
+		}
+	}
+}
+
+func TestGenerateSecretFrom(t *testing.T) {
+	key20 := []byte("12345678901234567890")
+	secret, err := GenerateSecretFrom(bytes.NewReader(key20), DefaultSecretSize)
+	if err != nil || !bytes.Equal(secret, key20) {
+		t.Logf("Expected %x, but was %x (%v)", key20, secret, err)
+		t.Fail()
+	}
+	if _, err := GenerateSecretFrom(bytes.NewReader(key20[:16]), DefaultSecretSize); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Logf("Expected %v, but was %v", io.ErrUnexpectedEOF, err)
+		t.Fail()
+	}
+	if _, err := GenerateSecretFrom(bytes.NewReader(nil), DefaultSecretSize); !errors.Is(err, io.EOF) {
+		t.Logf("Expected %v, but was %v", io.EOF, err)
+		t.Fail()
+	}
+	if _, err := GenerateSecretFrom(bytes.NewReader(key20), 10); !errors.Is(err, ErrSecretSize) {
+		t.Logf("Expected %v, but was %v", ErrSecretSize, err)
+		t.Fail()
+	}
+}
+
+func TestGenerateSecretBase32(t *testing.T) {
+	encoded, err := GenerateSecretBase32(DefaultSecretSize)
+	if err != nil {
+		t.Logf("Expected no error, but was %v", err)
+		t.FailNow()
+	}
+	secret, err := ParseBase32(encoded)
+	if err != nil || len(secret) != DefaultSecretSize {
+		t.Logf("Expected %d bytes, but was %d (%v)", DefaultSecretSize, len(secret), err)
+		t.Fail()
+	}
+}
+
+func TestValidateKeyLength(t *testing.T) {
+	key20 := []byte("12345678901234567890")
+	key32 := []byte("12345678901234567890123456789012")
+	key64 := []byte("1234567890123456789012345678901234567890123456789012345678901234")
+	testCases := []struct {
+		desc     string
+		key      []byte
+		hashFunc func() hash.Hash
+		expected error
+	}{
+		{desc: "SHA1", key: key20, hashFunc: sha1.New, expected: nil},
+		{desc: "SHA1 short", key: key20[:16], hashFunc: sha1.New, expected: ErrKeyLength},
+		{desc: "SHA256", key: key32, hashFunc: sha256.New, expected: nil},
+		{desc: "SHA256 short", key: key20, hashFunc: sha256.New, expected: ErrKeyLength},
+		{desc: "SHA512", key: key64, hashFunc: sha512.New, expected: nil},
+		{desc: "SHA512 short", key: key32, hashFunc: sha512.New, expected: ErrKeyLength},
+		{desc: "empty", key: nil, hashFunc: sha1.New, expected: ErrKeyLength},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			if err := ValidateKeyLength(tC.key, tC.hashFunc); !errors.Is(err, tC.expected) {
+				t.Logf("Expected %v, but was %v", tC.expected, err)
+				t.Fail()
+			}
+		})
+	}
+}
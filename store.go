@@ -0,0 +1,138 @@
+package otp
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrCounterNotFound is returned by a CounterStore for an unknown id.
+var ErrCounterNotFound = errors.New("otp: counter not found")
+
+// CounterStore persists HOTP counter values keyed by a user or device id.
+type CounterStore interface {
+	// Get returns the counter value of the next code for the id.
+	Get(id string) (Counter, error)
+	// Set stores the counter value of the next code for the id.
+	Set(id string, counter Counter) error
+}
+
+// CounterUpdater is implemented by a CounterStore that can update a counter
+// value atomically, e.g. within a database transaction. The update function
+// returns the new counter value and whether to store it.
+type CounterUpdater interface {
+	Update(id string, f func(Counter) (Counter, bool)) error
+}
+
+// MemoryCounterStore is an in-memory CounterStore for tests and small
+// deployments. It's safe for concurrent use.
+type MemoryCounterStore struct {
+	mu       sync.Mutex
+	counters map[string]Counter
+}
+
+// NewMemoryCounterStore creates a new empty MemoryCounterStore.
+func NewMemoryCounterStore() *MemoryCounterStore {
+	return &MemoryCounterStore{counters: make(map[string]Counter)}
+}
+
+// Get returns the counter value for the id, or ErrCounterNotFound.
+func (s *MemoryCounterStore) Get(id string) (Counter, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	counter, ok := s.counters[id]
+	if !ok {
+		return 0, ErrCounterNotFound
+	}
+
+	return counter, nil
+}
+
+// Set stores the counter value for the id.
+func (s *MemoryCounterStore) Set(id string, counter Counter) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.counters[id] = counter
+
+	return nil
+}
+
+// Update updates the counter value for the id atomically.
+func (s *MemoryCounterStore) Update(id string, f func(Counter) (Counter, bool)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	counter, ok := s.counters[id]
+	if !ok {
+		return ErrCounterNotFound
+	}
+	if counter, ok = f(counter); ok {
+		s.counters[id] = counter
+	}
+
+	return nil
+}
+
+// StoredHotp generates and validates HOTP codes with the counter values kept
+// in a CounterStore, so many tokens can be served by a single instance.
+type StoredHotp struct {
+	hotp  *hotp
+	store CounterStore
+}
+
+// NewStoredHotp creates a new StoredHotp generating and validating codes with
+// the given HOTP instance and the counter values of the store.
+func NewStoredHotp(hp *hotp, store CounterStore) *StoredHotp {
+	return &StoredHotp{hotp: hp, store: store}
+}
+
+// Next generates the code for the stored counter value of the id and advances
+// the counter.
+func (sh *StoredHotp) Next(id string, key []byte) (string, error) {
+	var code string
+	err := sh.update(id, func(counter Counter) (Counter, bool) {
+		code = sh.hotp.Generate(key, counter)
+		return counter + 1, true
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return code, nil
+}
+
+// Verify validates the code against the stored counter value of the id and up
+// to window counter values ahead of it. On success the stored counter advances
+// to one past the matched value, so the same code can't be accepted twice.
+// With WithNoResync configured, the window is ignored, and windows of more than
+// 1000 are clamped like ValidateLookAhead does. The update is atomic if the
+// store implements CounterUpdater. With an attempt limiter configured, denied
+// attempts fail with ErrRateLimited.
+func (sh *StoredHotp) Verify(id string, key []byte, code string, window int) (bool, error) {
+	return sh.hotp.limit(id, func() (bool, error) {
+		var ok bool
+		err := sh.update(id, func(counter Counter) (Counter, bool) {
+			var matched Counter
+			ok, matched = sh.hotp.ValidateLookAhead(key, code, counter, sh.hotp.resyncWindow(window))
+			return matched + 1, ok
+		})
+		if err != nil {
+			return false, err
+		}
+
+		return ok, nil
+	})
+}
+
+func (sh *StoredHotp) update(id string, f func(Counter) (Counter, bool)) error {
+	if updater, ok := sh.store.(CounterUpdater); ok {
+		return updater.Update(id, f)
+	}
+	counter, err := sh.store.Get(id)
+	if err != nil {
+		return err
+	}
+	if counter, ok := f(counter); ok {
+		return sh.store.Set(id, counter)
+	}
+
+	return nil
+}
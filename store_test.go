@@ -0,0 +1,104 @@
+package otp
+
+import (
+	"errors"
+	"sync"
+	"testing"
+)
+
+// mapCounterStore is a CounterStore without atomic updates.
+type mapCounterStore map[string]Counter
+
+func (s mapCounterStore) Get(id string) (Counter, error) {
+	counter, ok := s[id]
+	if !ok {
+		return 0, ErrCounterNotFound
+	}
+
+	return counter, nil
+}
+
+func (s mapCounterStore) Set(id string, counter Counter) error {
+	s[id] = counter
+
+	return nil
+}
+
+func TestMemoryCounterStore(t *testing.T) {
+	store := NewMemoryCounterStore()
+	if _, err := store.Get("alice"); !errors.Is(err, ErrCounterNotFound) {
+		t.Logf("Expected %v, but was %v", ErrCounterNotFound, err)
+		t.Fail()
+	}
+	if err := store.Set("alice", 42); err != nil {
+		t.Logf("Expected no error, but was %v", err)
+		t.Fail()
+	}
+	if counter, err := store.Get("alice"); err != nil || counter != 42 {
+		t.Logf("Expected counter %d, but was %d (%v)", 42, counter, err)
+		t.Fail()
+	}
+}
+
+func TestStoredHotp(t *testing.T) {
+	key20 := []byte("12345678901234567890")
+	for _, store := range []CounterStore{NewMemoryCounterStore(), mapCounterStore{}} {
+		sh := NewStoredHotp(NewHotp(), store)
+		if _, err := sh.Next("alice", key20); !errors.Is(err, ErrCounterNotFound) {
+			t.Logf("Expected %v, but was %v", ErrCounterNotFound, err)
+			t.Fail()
+		}
+		store.Set("alice", 0)
+		store.Set("bob", 5)
+		if code, err := sh.Next("alice", key20); err != nil || code != "755224" {
+			t.Logf("Expected code %s, but was %s (%v)", "755224", code, err)
+			t.Fail()
+		}
+		if ok, err := sh.Verify("alice", key20, "969429", 3); err != nil || !ok {
+			t.Logf("Code %s expected to be valid (%v)", "969429", err)
+			t.Fail()
+		}
+		if ok, _ := sh.Verify("alice", key20, "969429", 3); ok {
+			t.Logf("Code %s expected to be rejected on replay", "969429")
+			t.Fail()
+		}
+		if counter, _ := store.Get("alice"); counter != 4 {
+			t.Logf("Expected counter %d, but was %d", 4, counter)
+			t.Fail()
+		}
+		if ok, _ := sh.Verify("bob", key20, "969429", 3); ok {
+			t.Logf("Code %s expected to be invalid for bob", "969429")
+			t.Fail()
+		}
+		if counter, _ := store.Get("bob"); counter != 5 {
+			t.Logf("Expected counter %d, but was %d", 5, counter)
+			t.Fail()
+		}
+	}
+}
+
+func TestStoredHotpConcurrentVerify(t *testing.T) {
+	key20 := []byte("12345678901234567890")
+	store := NewMemoryCounterStore()
+	store.Set("alice", 0)
+	sh := NewStoredHotp(NewHotp(), store)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	accepted := 0
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if ok, _ := sh.Verify("alice", key20, "359152", 5); ok {
+				mu.Lock()
+				accepted++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if accepted != 1 {
+		t.Logf("Expected the code accepted once, but was %d times", accepted)
+		t.Fail()
+	}
+}
@@ -0,0 +1,242 @@
+package otp
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidURI is returned for a malformed URI or a scheme other than
+// otpauth.
+var ErrInvalidURI = errors.New("otp: invalid otpauth URI")
+
+// ErrURIType is returned for an otpauth URI of another OTP type than expected,
+// e.g. an hotp URI parsed by ParseTotpURI.
+var ErrURIType = errors.New("otp: unsupported otpauth URI type")
+
+// ErrMissingSecret is returned for an otpauth URI without the secret parameter.
+var ErrMissingSecret = errors.New("otp: missing secret")
+
+// ErrInvalidSecret is returned for an otpauth URI with a secret that isn't
+// valid Base32.
+var ErrInvalidSecret = errors.New("otp: invalid secret")
+
+// ErrURIParameter is returned for an otpauth URI with a non-numeric digits,
+// period or counter parameter. Unknown algorithms are reported with
+// ErrUnknownHash, and out of range values with the errors of NewTotpE and
+// NewHotpE, e.g. ErrDigits.
+var ErrURIParameter = errors.New("otp: invalid otpauth URI parameter")
+
+// ErrPeriod is returned by URIE for a time step that isn't a whole number of
+// seconds, which the period parameter of otpauth URIs can't carry.
+var ErrPeriod = errors.New("otp: time step not representable as an otpauth period")
+
+// URI returns the otpauth:// provisioning URI for enrolling the secret key
+// into an authenticator app in TOTP mode. The URI carries the configured
+// digits, algorithm and period. Authenticator apps only support periods in
+// whole seconds and the SHA1, SHA256 and SHA512 algorithms. An empty account
+// defaults to the label configured by WithLabel. The period is truncated to
+// whole seconds, so the app computes other codes for time steps like 1.5s:
+// URIE rejects them, and CheckCompatibility reports them.
+func (tp *totp) URI(issuer, account string, key []byte) string {
+	period := strconv.FormatInt(int64(tp.timeStep/time.Second), 10)
+
+	return tp.hotp.uri("totp", issuer, account, key, "period", period)
+}
+
+// URIE returns the otpauth:// provisioning URI like URI, but returns ErrPeriod
+// if the time step isn't a whole number of seconds.
+func (tp *totp) URIE(issuer, account string, key []byte) (string, error) {
+	if tp.timeStep%time.Second != 0 {
+		return "", fmt.Errorf("%w, must be whole seconds, but was %v", ErrPeriod, tp.timeStep)
+	}
+
+	return tp.URI(issuer, account, key), nil
+}
+
+// URI returns the otpauth:// provisioning URI for enrolling the secret key
+// into an authenticator app in HOTP mode, starting at the given counter. An
+// empty account defaults to the label configured by WithLabel.
+func (hp *hotp) URI(issuer, account string, key []byte, counter Counter) string {
+	return hp.uri("hotp", issuer, account, key, "counter", strconv.FormatUint(uint64(counter), 10))
+}
+
+// uri builds the URI following the Key Uri Format of Google Authenticator:
+// otpauth://TYPE/ISSUER:ACCOUNT?secret=SECRET&issuer=ISSUER&... The issuer is
+// duplicated in the label and the query, as recommended for compatibility.
+func (hp *hotp) uri(typ, issuer, account string, key []byte, param, value string) string {
+	if account == "" {
+		account = hp.label
+	}
+	var b strings.Builder
+	b.WriteString("otpauth://" + typ + "/")
+	if issuer != "" {
+		b.WriteString(escapeLabel(issuer) + ":")
+	}
+	b.WriteString(escapeLabel(account))
+	b.WriteString("?secret=" + Secret(key).Base32())
+	if issuer != "" {
+		b.WriteString("&issuer=" + escapeQuery(issuer))
+	}
+	if name := HashName(hp.hashFunc); name != "" {
+		b.WriteString("&algorithm=" + name)
+	}
+	b.WriteString("&digits=" + strconv.Itoa(hp.digits))
+	b.WriteString("&" + param + "=" + value)
+
+	return b.String()
+}
+
+func escapeLabel(s string) string {
+	return strings.ReplaceAll(url.PathEscape(s), ":", "%3A")
+}
+
+func escapeQuery(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
+
+// ParseTotpURI parses an otpauth://totp/ provisioning URI. Returns a TOTP
+// instance configured with the digits, algorithm and period of the URI, and
+// the decoded secret key. The account name of the URI becomes the label of the
+// instance, so URI with an empty account reproduces it, and URILabel returns
+// the issuer along with it. Errors name the offending parameter and match the
+// sentinel errors like ErrMissingSecret with errors.Is.
+func ParseTotpURI(s string) (*totp, Secret, error) {
+	query, key, opts, err := parseURI(s, "totp")
+	if err != nil {
+		return nil, nil, err
+	}
+	totpOpts := make([]TotpOption, 0, len(opts)+1)
+	for _, opt := range opts {
+		totpOpts = append(totpOpts, opt)
+	}
+	if period := query.Get("period"); period != "" {
+		secs, err := strconv.ParseUint(period, 10, 32)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w, period must be a number of seconds but was %q", ErrURIParameter, period)
+		}
+		totpOpts = append(totpOpts, WithTimeStep(time.Duration(secs)*time.Second))
+	}
+	tp, err := NewTotpE(totpOpts...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return tp, key, nil
+}
+
+// ParseHotpURI parses an otpauth://hotp/ provisioning URI. Returns an HOTP
+// instance configured with the digits and algorithm of the URI, the decoded
+// secret key, and the initial counter value. The account name becomes the
+// label like with ParseTotpURI. Errors match the sentinel errors like
+// ParseTotpURI.
+func ParseHotpURI(s string) (*hotp, Secret, Counter, error) {
+	query, key, opts, err := parseURI(s, "hotp")
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	counter, err := strconv.ParseUint(query.Get("counter"), 10, 64)
+	if err != nil {
+		return nil, nil, 0, fmt.Errorf("%w, counter must be a number but was %q", ErrURIParameter, query.Get("counter"))
+	}
+	hp, err := NewHotpE(opts...)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	return hp, key, Counter(counter), nil
+}
+
+// URILabel returns the issuer and the account name of an otpauth:// URI of
+// either type. The issuer parameter takes precedence over the issuer prefix of
+// the label, as the Key Uri Format recommends. Both are empty if missing.
+func URILabel(s string) (issuer, account string, err error) {
+	u, err := parseScheme(s)
+	if err != nil {
+		return "", "", err
+	}
+
+	return parseLabel(u)
+}
+
+// parseScheme parses the URI and checks the otpauth scheme.
+func parseScheme(s string) (*url.URL, error) {
+	u, err := url.Parse(s)
+	if err != nil {
+		return nil, fmt.Errorf("%w, %v", ErrInvalidURI, err)
+	}
+	if u.Scheme != "otpauth" {
+		return nil, fmt.Errorf("%w, scheme must be otpauth but was %q", ErrInvalidURI, u.Scheme)
+	}
+
+	return u, nil
+}
+
+// parseLabel splits the ISSUER:ACCOUNT label of the URI path. The escaped path
+// is split, as escapeLabel escapes the colons within the issuer and account.
+func parseLabel(u *url.URL) (string, string, error) {
+	issuer, account := "", strings.TrimPrefix(u.EscapedPath(), "/")
+	if i := strings.IndexByte(account, ':'); i >= 0 {
+		issuer, account = account[:i], account[i+1:]
+	}
+	issuer, err := url.PathUnescape(issuer)
+	if err != nil {
+		return "", "", fmt.Errorf("%w, %v", ErrInvalidURI, err)
+	}
+	account, err = url.PathUnescape(account)
+	if err != nil {
+		return "", "", fmt.Errorf("%w, %v", ErrInvalidURI, err)
+	}
+	if query := u.Query().Get("issuer"); query != "" {
+		issuer = query
+	}
+
+	return issuer, strings.TrimLeft(account, " "), nil
+}
+
+// parseURI parses the parameters shared by both URI types.
+func parseURI(s, typ string) (url.Values, Secret, []HotpOption, error) {
+	u, err := parseScheme(s)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if u.Host != typ {
+		return nil, nil, nil, fmt.Errorf("%w, type must be %s but was %q", ErrURIType, typ, u.Host)
+	}
+	query := u.Query()
+	if query.Get("secret") == "" {
+		return nil, nil, nil, ErrMissingSecret
+	}
+	key, err := ParseBase32(query.Get("secret"))
+	if err != nil {
+		// The secret itself is left out of the error, which may end up in logs.
+		return nil, nil, nil, fmt.Errorf("%w, secret must be Base32", ErrInvalidSecret)
+	}
+	_, account, err := parseLabel(u)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	var opts []HotpOption
+	if account != "" {
+		opts = append(opts, WithLabel(account))
+	}
+	if name := query.Get("algorithm"); name != "" {
+		f, err := HashByName(name)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		opts = append(opts, WithHash(f))
+	}
+	if digits := query.Get("digits"); digits != "" {
+		n, err := strconv.Atoi(digits)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("%w, digits must be a number but was %q", ErrURIParameter, digits)
+		}
+		opts = append(opts, WithDigits(n))
+	}
+
+	return query, key, opts, nil
+}
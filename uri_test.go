@@ -0,0 +1,214 @@
+package otp
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"crypto/sha512"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTotpURI(t *testing.T) {
+	key := []byte("12345678901234567890")
+	testCases := []struct {
+		totp    *totp
+		issuer  string
+		account string
+		uri     string
+	}{
+		{
+			totp:    NewTotp(),
+			issuer:  "Example",
+			account: "alice@google.com",
+			uri:     "otpauth://totp/Example:alice@google.com?secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ&issuer=Example&algorithm=SHA1&digits=6&period=30",
+		},
+		{
+			totp:    NewTotp(WithDigits(8), WithHash(sha256.New), WithTimeStep(60*time.Second)),
+			issuer:  "ACME Co",
+			account: "john doe",
+			uri:     "otpauth://totp/ACME%20Co:john%20doe?secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ&issuer=ACME%20Co&algorithm=SHA256&digits=8&period=60",
+		},
+		{
+			totp:    NewTotp(WithHash(sha512.New)),
+			issuer:  "",
+			account: "alice",
+			uri:     "otpauth://totp/alice?secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ&algorithm=SHA512&digits=6&period=30",
+		},
+		{
+			totp:    NewTotp(),
+			issuer:  "A&B:C",
+			account: "x/y?z",
+			uri:     "otpauth://totp/A&B%3AC:x%2Fy%3Fz?secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ&issuer=A%26B%3AC&algorithm=SHA1&digits=6&period=30",
+		},
+		{
+			totp:    NewTotp(WithHash(md5.New)),
+			issuer:  "Example",
+			account: "alice",
+			uri:     "otpauth://totp/Example:alice?secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ&issuer=Example&digits=6&period=30",
+		},
+	}
+	for _, tC := range testCases {
+		t.Run("TOTP provisioning URI", func(t *testing.T) {
+			if uri := tC.totp.URI(tC.issuer, tC.account, key); uri != tC.uri {
+				t.Logf("Expected URI %s, but was %s", tC.uri, uri)
+				t.Fail()
+			}
+		})
+	}
+}
+
+func TestTotpURIE(t *testing.T) {
+	key := []byte("12345678901234567890")
+	if uri, err := NewTotp().URIE("Example", "alice", key); err != nil || uri != NewTotp().URI("Example", "alice", key) {
+		t.Logf("Expected the URI, but was %s, %v", uri, err)
+		t.Fail()
+	}
+	if _, err := NewTotp(WithTimeStep(1500*time.Millisecond)).URIE("Example", "alice", key); !errors.Is(err, ErrPeriod) {
+		t.Logf("Expected %v, but was %v", ErrPeriod, err)
+		t.Fail()
+	}
+}
+
+func TestHotpURI(t *testing.T) {
+	key := []byte("12345678901234567890")
+	expected := "otpauth://hotp/Example:alice?secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ&issuer=Example&algorithm=SHA1&digits=6&counter=42"
+	if uri := NewHotp().URI("Example", "alice", key, 42); uri != expected {
+		t.Logf("Expected URI %s, but was %s", expected, uri)
+		t.Fail()
+	}
+	if uri := NewHotp(WithLabel("alice")).URI("Example", "", key, 42); uri != expected {
+		t.Logf("Expected URI %s with the label account, but was %s", expected, uri)
+		t.Fail()
+	}
+}
+
+func TestParseTotpURI(t *testing.T) {
+	key := []byte("12345678901234567890")
+	testCases := []struct {
+		totp *totp
+	}{
+		{totp: NewTotp()},
+		{totp: NewTotp(WithDigits(8), WithHash(sha256.New), WithTimeStep(60*time.Second))},
+		{totp: NewTotp(WithDigits(7), WithHash(sha512.New), WithTimeStep(10*time.Second))},
+	}
+	for _, tC := range testCases {
+		t.Run("TOTP provisioning URI round trip", func(t *testing.T) {
+			uri := tC.totp.URI("Example", "alice", key)
+			totp, secret, err := ParseTotpURI(uri)
+			if err != nil {
+				t.Logf("Expected no error, but was %v", err)
+				t.FailNow()
+			}
+			if parsed := totp.URI("Example", "", secret); parsed != uri {
+				t.Logf("Expected URI %s, but was %s", uri, parsed)
+				t.Fail()
+			}
+			unix := time.Unix(1111111109, 0)
+			if code, expected := totp.Code(secret, unix), tC.totp.Code(key, unix); code != expected {
+				t.Logf("Expected code %s, but was %s", expected, code)
+				t.Fail()
+			}
+		})
+	}
+}
+
+func TestParseTotpURIDefaults(t *testing.T) {
+	totp, secret, err := ParseTotpURI("otpauth://totp/Example:alice?secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ&issuer=Example")
+	if err != nil {
+		t.Logf("Expected no error, but was %v", err)
+		t.FailNow()
+	}
+	if code := totp.Code(secret, time.Unix(59, 0)); code != "287082" {
+		t.Logf("Expected code %s, but was %s", "287082", code)
+		t.Fail()
+	}
+}
+
+func TestParseHotpURI(t *testing.T) {
+	key := []byte("12345678901234567890")
+	uri := NewHotp(WithDigits(8), WithHash(sha256.New)).URI("Example", "alice", key, 42)
+	hotp, secret, counter, err := ParseHotpURI(uri)
+	if err != nil {
+		t.Logf("Expected no error, but was %v", err)
+		t.FailNow()
+	}
+	if counter != 42 {
+		t.Logf("Expected counter %d, but was %d", 42, counter)
+		t.Fail()
+	}
+	if parsed := hotp.URI("Example", "", secret, counter); parsed != uri {
+		t.Logf("Expected URI %s, but was %s", uri, parsed)
+		t.Fail()
+	}
+}
+
+func TestURILabel(t *testing.T) {
+	testCases := []struct {
+		uri     string
+		issuer  string
+		account string
+	}{
+		{uri: NewTotp().URI("Example", "alice@google.com", []byte("12345678901234567890")), issuer: "Example", account: "alice@google.com"},
+		{uri: NewHotp().URI("A&B:C", "x/y?z", []byte("12345678901234567890"), 0), issuer: "A&B:C", account: "x/y?z"},
+		{uri: "otpauth://totp/Example:%20alice?secret=GEZDGNBV", issuer: "Example", account: "alice"},
+		{uri: "otpauth://totp/Old:alice?secret=GEZDGNBV&issuer=New", issuer: "New", account: "alice"},
+		{uri: "otpauth://totp/alice?secret=GEZDGNBV", issuer: "", account: "alice"},
+		{uri: "otpauth://totp/?secret=GEZDGNBV", issuer: "", account: ""},
+	}
+	for _, tC := range testCases {
+		issuer, account, err := URILabel(tC.uri)
+		if err != nil || issuer != tC.issuer || account != tC.account {
+			t.Logf("Expected %q, %q for %s, but was %q, %q (%v)", tC.issuer, tC.account, tC.uri, issuer, account, err)
+			t.Fail()
+		}
+	}
+	if _, _, err := URILabel("https://totp/alice"); !errors.Is(err, ErrInvalidURI) {
+		t.Logf("Expected %v, but was %v", ErrInvalidURI, err)
+		t.Fail()
+	}
+}
+
+func TestParseURIInvalid(t *testing.T) {
+	testCases := []struct {
+		uri string
+		err error
+	}{
+		{uri: "otpauth://totp/alice", err: ErrMissingSecret},
+		{uri: "otpauth://hotp/alice?secret=GEZDGNBV", err: ErrURIType},
+		{uri: "otpauth://motp/alice?secret=GEZDGNBV", err: ErrURIType},
+		{uri: "https://totp/alice?secret=GEZDGNBV", err: ErrInvalidURI},
+		{uri: "otpauth://totp/alice?secret=GEZ1", err: ErrInvalidSecret},
+		{uri: "otpauth://totp/alice?secret=GEZDGNBV&algorithm=MD5", err: ErrUnknownHash},
+		{uri: "otpauth://totp/alice?secret=GEZDGNBV&digits=six", err: ErrURIParameter},
+		{uri: "otpauth://totp/alice?secret=GEZDGNBV&digits=12", err: ErrDigits},
+		{uri: "otpauth://totp/alice?secret=GEZDGNBV&period=-30", err: ErrURIParameter},
+		{uri: "otpauth://totp/alice?secret=GEZDGNBV&period=0", err: ErrTimeStep},
+		{uri: "%", err: ErrInvalidURI},
+	}
+	for _, tC := range testCases {
+		if _, _, err := ParseTotpURI(tC.uri); !errors.Is(err, tC.err) {
+			t.Logf("Expected %v for %s, but was %v", tC.err, tC.uri, err)
+			t.Fail()
+		}
+	}
+	testCases = []struct {
+		uri string
+		err error
+	}{
+		{uri: "otpauth://hotp/alice?secret=GEZDGNBV", err: ErrURIParameter},
+		{uri: "otpauth://hotp/alice?secret=GEZDGNBV&counter=-1", err: ErrURIParameter},
+		{uri: "otpauth://totp/alice?secret=GEZDGNBV&counter=1", err: ErrURIType},
+	}
+	for _, tC := range testCases {
+		if _, _, _, err := ParseHotpURI(tC.uri); !errors.Is(err, tC.err) {
+			t.Logf("Expected %v for %s, but was %v", tC.err, tC.uri, err)
+			t.Fail()
+		}
+	}
+	if _, _, err := ParseTotpURI("otpauth://totp/alice?secret=GEZ1"); strings.Contains(err.Error(), "GEZ1") {
+		t.Logf("Expected the secret to be left out of %v", err)
+		t.Fail()
+	}
+}
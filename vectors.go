@@ -0,0 +1,79 @@
+package otp
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrVectorMismatch is returned by RunVectors for a test vector whose code
+// doesn't match the generated one.
+var ErrVectorMismatch = errors.New("otp: test vector mismatch")
+
+// RunVectors checks TOTP test vectors read as CSV rows of the form
+// "time,key,algorithm,digits,expected", like the RFC 6238 test vectors: the
+// Unix time in seconds, the hex-encoded secret key, the hash name accepted by
+// HashByName, the number of digits and the expected code. Codes use the
+// default 30 seconds time step and epoch. A header row starting with "time"
+// and lines starting with "#" are skipped. Every vector is checked with both
+// Code and ValidateAt. Returns ErrVectorMismatch for the first mismatching
+// vector, or an error for the first malformed row, with its line number.
+func RunVectors(r io.Reader) error {
+	cr := csv.NewReader(r)
+	cr.Comment = '#'
+	cr.FieldsPerRecord = 5
+	cr.TrimLeadingSpace = true
+	for first := true; ; first = false {
+		record, err := cr.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("otp: invalid test vectors: %w", err)
+		}
+		line, _ := cr.FieldPos(0)
+		if first && strings.EqualFold(record[0], "time") {
+			continue
+		}
+		if err := runVector(record); err != nil {
+			return fmt.Errorf("%w, at line %d", err, line)
+		}
+	}
+}
+
+// runVector checks a single test vector record.
+func runVector(record []string) error {
+	unix, err := strconv.ParseInt(record[0], 10, 64)
+	if err != nil {
+		return fmt.Errorf("otp: invalid test vector time %q", record[0])
+	}
+	key, err := ParseHexSecret(record[1])
+	if err != nil {
+		return err
+	}
+	f, err := HashByName(record[2])
+	if err != nil {
+		return err
+	}
+	digits, err := strconv.Atoi(record[3])
+	if err != nil {
+		return fmt.Errorf("otp: invalid test vector digits %q", record[3])
+	}
+	tp, err := NewTotpE(WithHash(f), WithDigits(digits))
+	if err != nil {
+		return err
+	}
+	t, expected := time.Unix(unix, 0), record[4]
+	if code := tp.Code(key, t); code != expected {
+		return fmt.Errorf("%w, expected %s, but was %s", ErrVectorMismatch, expected, code)
+	}
+	if !tp.ValidateAt(key, expected, t) {
+		return fmt.Errorf("%w, code %s rejected", ErrVectorMismatch, expected)
+	}
+
+	return nil
+}
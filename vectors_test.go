@@ -0,0 +1,56 @@
+package otp
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+const (
+	hex20 = "3132333435363738393031323334353637383930"
+	hex32 = "3132333435363738393031323334353637383930313233343536373839303132"
+	hex64 = "31323334353637383930313233343536373839303132333435363738393031323334353637383930313233343536373839303132333435363738393031323334"
+)
+
+func TestRunVectors(t *testing.T) {
+	vectors := "time,key,algorithm,digits,expected\n" +
+		"# RFC 6238 appendix B\n" +
+		"59," + hex20 + ",SHA1,8,94287082\n" +
+		"59," + hex32 + ",SHA256,8,46119246\n" +
+		"59," + hex64 + ",SHA512,8,90693936\n" +
+		"1111111109," + hex20 + ",sha1,8,07081804\n" +
+		"20000000000," + hex64 + ",SHA512,8,47863826\n" +
+		"59," + hex20 + ",SHA1,6,287082\n"
+	if err := RunVectors(strings.NewReader(vectors)); err != nil {
+		t.Logf("Expected no error, but was %v", err)
+		t.Fail()
+	}
+	testCases := []struct {
+		desc    string
+		vectors string
+		err     error
+	}{
+		{desc: "mismatch", vectors: "59," + hex20 + ",SHA1,8,94287083\n", err: ErrVectorMismatch},
+		{desc: "unknown hash", vectors: "59," + hex20 + ",MD5,8,94287082\n", err: ErrUnknownHash},
+		{desc: "digits out of range", vectors: "59," + hex20 + ",SHA1,4,7082\n", err: ErrDigits},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			if err := RunVectors(strings.NewReader(tC.vectors)); !errors.Is(err, tC.err) {
+				t.Logf("Expected %v, but was %v", tC.err, err)
+				t.Fail()
+			}
+		})
+	}
+	for _, vectors := range []string{"x," + hex20 + ",SHA1,8,94287082\n", "59,zz,SHA1,8,94287082\n", "59," + hex20 + ",SHA1,8\n"} {
+		if err := RunVectors(strings.NewReader(vectors)); err == nil {
+			t.Logf("Expected an error for %q", vectors)
+			t.Fail()
+		}
+	}
+	err := RunVectors(strings.NewReader("59," + hex20 + ",SHA1,8,94287082\n59," + hex20 + ",SHA1,8,00000000\n"))
+	if err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Logf("Expected a mismatch at line 2, but was %v", err)
+		t.Fail()
+	}
+}
@@ -0,0 +1,37 @@
+package otp
+
+import "crypto/subtle"
+
+// Verifier validates HOTP codes of a single secret key, reusing the keyed HMAC
+// across counter values instead of keying a new one for every code. A Verifier
+// is not safe for concurrent use.
+type Verifier struct {
+	g   *generator
+	key []byte
+}
+
+// Verifier returns a Verifier of the secret key with the configuration of the
+// HOTP instance.
+func (hp *hotp) Verifier(key []byte) *Verifier {
+	return &Verifier{g: hp.keyedGenerator(key), key: append([]byte(nil), key...)}
+}
+
+// Verify validates the code against the counter value like hotp.Validate.
+func (v *Verifier) Verify(code string, counter Counter) bool {
+	hp := v.g.hp
+	code, ok := hp.input(code)
+	ok = ok && equal(code, v.g.generate(counter)) == 1
+	hp.observe(ok, 0)
+
+	return ok
+}
+
+// Reset switches the Verifier to another secret key, e.g. for the next request
+// of a validation service. The keyed HMAC is kept when the key is unchanged.
+func (v *Verifier) Reset(key []byte) {
+	if subtle.ConstantTimeCompare(key, v.key) == 1 {
+		return
+	}
+	v.g.rekey(key)
+	v.key = append(v.key[:0], key...)
+}
@@ -0,0 +1,46 @@
+package otp
+
+import "testing"
+
+func TestVerifier(t *testing.T) {
+	key20 := []byte("12345678901234567890")
+	key32 := []byte("12345678901234567890123456789012")
+	verifier := NewHotp().Verifier(key20)
+	codes := []string{"755224", "287082", "359152", "969429", "338314"}
+	for counter, code := range codes {
+		if !verifier.Verify(code, Counter(counter)) {
+			t.Logf("Code %s expected to be valid for counter %d", code, counter)
+			t.Fail()
+		}
+	}
+	if verifier.Verify("755224", 1) || verifier.Verify("75522", 0) {
+		t.Log("Expected wrong codes to be invalid")
+		t.Fail()
+	}
+	verifier.Reset(key32)
+	expected := NewHotp().Generate(key32, 0)
+	if !verifier.Verify(expected, 0) || verifier.Verify("755224", 0) {
+		t.Logf("Expected code %s of the new key only to be valid", expected)
+		t.Fail()
+	}
+	verifier.Reset(key20)
+	verifier.Reset(key20)
+	if !verifier.Verify("287082", 1) {
+		t.Log("Expected codes of the original key to be valid after the reset")
+		t.Fail()
+	}
+	strict := NewHotp(WithStrictMode(true)).Verifier(key20[:10])
+	if strict.Verify(NewHotp().Generate(key20[:10], 0), 0) {
+		t.Log("Expected short keys to be rejected in strict mode")
+		t.Fail()
+	}
+}
+
+func BenchmarkVerifier(b *testing.B) {
+	key := []byte("12345678901234567890")
+	verifier := NewHotp().Verifier(key)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		verifier.Verify("000000", Counter(i))
+	}
+}
@@ -0,0 +1,35 @@
+package otp
+
+import "time"
+
+// Window describes the time step of a TOTP code: its counter value and the
+// time range in which the code is current.
+type Window struct {
+	Counter Counter
+	// Start is the start of the time step, inclusive
+	Start time.Time
+	// End is the start of the next time step, when the code expires
+	End time.Time
+}
+
+// Window returns the time step containing t. Times before the epoch belong to
+// the first time step, like in At.
+func (tp *totp) Window(t time.Time) Window {
+	counter := tp.At(t)
+
+	return Window{
+		Counter: counter,
+		Start:   tp.start(counter),
+		End:     tp.start(counter + 1),
+	}
+}
+
+// Remaining returns the time left at t until the window ends, or 0 once it
+// ended.
+func (w Window) Remaining(t time.Time) time.Duration {
+	if remaining := w.End.Sub(t); remaining > 0 {
+		return remaining
+	}
+
+	return 0
+}
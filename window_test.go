@@ -0,0 +1,44 @@
+package otp
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWindow(t *testing.T) {
+	testCases := []struct {
+		totp      *totp
+		unix      time.Time
+		counter   Counter
+		start     time.Time
+		end       time.Time
+		remaining time.Duration
+	}{
+		{totp: NewTotp(), unix: time.Unix(59, 0), counter: 1, start: time.Unix(30, 0), end: time.Unix(60, 0), remaining: time.Second},
+		{totp: NewTotp(), unix: time.Unix(60, 0), counter: 2, start: time.Unix(60, 0), end: time.Unix(90, 0), remaining: 30 * time.Second},
+		{totp: NewTotp(WithTimeStep(10 * time.Second)), unix: time.Unix(1111111109, 500000000), counter: 111111110, start: time.Unix(1111111100, 0), end: time.Unix(1111111110, 0), remaining: 500 * time.Millisecond},
+		{totp: NewTotp(WithEpoch(100)), unix: time.Unix(59, 0), counter: 0, start: time.Unix(100, 0), end: time.Unix(130, 0), remaining: 71 * time.Second},
+		{totp: NewTotp(WithEpoch(100)), unix: time.Unix(145, 0), counter: 1, start: time.Unix(130, 0), end: time.Unix(160, 0), remaining: 15 * time.Second},
+	}
+	for _, tC := range testCases {
+		t.Run("Time step window", func(t *testing.T) {
+			w := tC.totp.Window(tC.unix)
+			if w.Counter != tC.counter || !w.Start.Equal(tC.start) || !w.End.Equal(tC.end) {
+				t.Logf("Expected %d [%v, %v), but was %d [%v, %v)", tC.counter, tC.start, tC.end, w.Counter, w.Start, w.End)
+				t.Fail()
+			}
+			if remaining := w.Remaining(tC.unix); remaining != tC.remaining {
+				t.Logf("Expected %v, but was %v", tC.remaining, remaining)
+				t.Fail()
+			}
+			if remaining := tC.totp.RemainingTime(tC.unix); remaining != tC.remaining {
+				t.Logf("Expected %v like RemainingTime, but was %v", tC.remaining, remaining)
+				t.Fail()
+			}
+		})
+	}
+	if remaining := NewTotp().Window(time.Unix(59, 0)).Remaining(time.Unix(61, 0)); remaining != 0 {
+		t.Logf("Expected no time remaining after the window, but was %v", remaining)
+		t.Fail()
+	}
+}